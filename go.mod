@@ -3,10 +3,10 @@ module github.com/turbot/steampipe-plugin-k8s
 go 1.16
 
 require (
+	github.com/hashicorp/go-hclog v0.14.1
 	github.com/turbot/go-kit v0.1.3
+	github.com/turbot/steampipe-plugin-sdk v0.2.6
 	k8s.io/api v0.20.2
 	k8s.io/apimachinery v0.20.2
 	k8s.io/client-go v0.20.0
-	github.com/turbot/steampipe-plugin-sdk v0.2.6
-	
 )
@@ -0,0 +1,168 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// defaultExecTimeout bounds how long we wait for an exec credential plugin
+// (e.g. aws eks get-token) when the connection does not set exec_timeout
+const defaultExecTimeout = 60 * time.Second
+
+// ExecWrapperArg is the first argument the plugin binary is run with to act as
+// the timeout wrapper of an exec credential plugin
+const ExecWrapperArg = "exec-credential-timeout"
+
+// configureExecProvider applies the exec_env connection argument to the exec
+// credential plugin of config, if it has one, and checks that the plugin's
+// command can be found. The plugin is then run through the timeout wrapper, as
+// client-go runs it without a deadline on every credential refresh.
+func configureExecProvider(config *rest.Config, k8sConfig k8sConfig, execTimeout time.Duration) error {
+	if config.ExecProvider == nil {
+		return nil
+	}
+
+	// variables are appended after the plugin's own env, so they take precedence
+	for _, variable := range k8sConfig.ExecEnv {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid exec_env entry %q, expected NAME=value", variable)
+		}
+		config.ExecProvider.Env = append(config.ExecProvider.Env, clientcmdapi.ExecEnvVar{Name: parts[0], Value: parts[1]})
+	}
+
+	if _, err := exec.LookPath(config.ExecProvider.Command); err != nil {
+		msg := fmt.Sprintf("exec credential plugin %q not found", config.ExecProvider.Command)
+		if config.ExecProvider.InstallHint != "" {
+			msg = fmt.Sprintf("%s: %s", msg, config.ExecProvider.InstallHint)
+		}
+		return fmt.Errorf("%s: %v", msg, err)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot wrap exec credential plugin %q with exec_timeout: %v", config.ExecProvider.Command, err)
+	}
+	args := append([]string{ExecWrapperArg, execTimeout.String(), config.ExecProvider.Command}, config.ExecProvider.Args...)
+	config.ExecProvider.Command = self
+	config.ExecProvider.Args = args
+
+	return nil
+}
+
+// RunExecWrapper runs an exec credential plugin, given as the command and its
+// arguments after the timeout, and kills it if it has not exited by then. The
+// plugin inherits the environment and standard streams of the wrapper, so it
+// sees the same input client-go passed to the wrapper. It returns the exit code
+// of the plugin.
+func RunExecWrapper(args []string) int {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s TIMEOUT COMMAND [ARG...]\n", ExecWrapperArg)
+		return 2
+	}
+	timeout, err := time.ParseDuration(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid exec credential plugin timeout %q: %v\n", args[0], err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[1], args[2:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		fmt.Fprintf(os.Stderr, "exec credential plugin %q did not return credentials within %s\n", args[1], timeout)
+		return 1
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "exec credential plugin %q: %v\n", args[1], err)
+		return 1
+	}
+	return 0
+}
+
+// getExecTimeout returns how long to wait for the exec credential plugin
+func getExecTimeout(k8sConfig k8sConfig) (time.Duration, error) {
+	if k8sConfig.ExecTimeout == nil {
+		return defaultExecTimeout, nil
+	}
+	return parsePositiveDuration("exec_timeout", *k8sConfig.ExecTimeout)
+}
+
+// getDirectConfig builds a client config from the host, token and certificate
+// connection arguments, without reading any kubeconfig. It returns nil if the
+// connection does not set host.
+func getDirectConfig(k8sConfig k8sConfig) (*rest.Config, error) {
+	if k8sConfig.Host == nil || *k8sConfig.Host == "" {
+		return nil, nil
+	}
+
+	config := &rest.Config{Host: *k8sConfig.Host}
+
+	if k8sConfig.Token != nil {
+		config.BearerToken = *k8sConfig.Token
+	}
+	// client-go rereads the token file periodically, so rotated tokens are picked up
+	if k8sConfig.TokenFile != nil {
+		path, err := expandPath(*k8sConfig.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		config.BearerTokenFile = path
+	}
+
+	if k8sConfig.ClientCertificate != nil {
+		data, file, err := pemDataOrFile(*k8sConfig.ClientCertificate)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSClientConfig.CertData, config.TLSClientConfig.CertFile = data, file
+	}
+	if k8sConfig.ClientKey != nil {
+		data, file, err := pemDataOrFile(*k8sConfig.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSClientConfig.KeyData, config.TLSClientConfig.KeyFile = data, file
+	}
+	if k8sConfig.ClusterCACertificate != nil {
+		data, file, err := pemDataOrFile(*k8sConfig.ClusterCACertificate)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSClientConfig.CAData, config.TLSClientConfig.CAFile = data, file
+	}
+	if k8sConfig.InsecureSkipTLSVerify != nil {
+		config.TLSClientConfig.Insecure = *k8sConfig.InsecureSkipTLSVerify
+	}
+
+	return config, nil
+}
+
+// pemDataOrFile interprets a certificate or key argument, which may hold
+// either PEM-encoded data or the path to a PEM file
+func pemDataOrFile(value string) ([]byte, string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), "", nil
+	}
+	path, err := expandPath(value)
+	if err != nil {
+		return nil, "", err
+	}
+	return nil, path, nil
+}
@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// contentTypeProtobuf requests the protobuf encoding, which is much cheaper to
+// decode than JSON for large lists of built-in resources
+const contentTypeProtobuf = "protobuf"
+
+// throttleLogThreshold is how long a request must wait on the client-side rate
+// limiter before we log it
+const throttleLogThreshold = time.Second
+
+// configureClientOptions applies the qps, burst, request_timeout, proxy_url
+// and content_type connection arguments to config
+func configureClientOptions(config *rest.Config, k8sConfig k8sConfig, logger hclog.Logger) error {
+	if k8sConfig.QPS != nil {
+		config.QPS = float32(*k8sConfig.QPS)
+	}
+	if k8sConfig.Burst != nil {
+		config.Burst = *k8sConfig.Burst
+	}
+
+	qps, burst := config.QPS, config.Burst
+	if qps == 0 {
+		qps = rest.DefaultQPS
+	}
+	if burst == 0 {
+		burst = rest.DefaultBurst
+	}
+	config.RateLimiter = &throttleLogger{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		logger:      logger,
+		host:        config.Host,
+	}
+
+	if k8sConfig.RequestTimeout != nil {
+		timeout, err := parsePositiveDuration("request_timeout", *k8sConfig.RequestTimeout)
+		if err != nil {
+			return err
+		}
+		config.Timeout = timeout
+	}
+
+	if k8sConfig.ProxyURL != nil {
+		proxyURL, err := url.Parse(*k8sConfig.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy_url %q: %v", *k8sConfig.ProxyURL, err)
+		}
+		config.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if k8sConfig.ContentType != nil {
+		switch *k8sConfig.ContentType {
+		case contentTypeProtobuf:
+			// resources without a protobuf encoding, such as custom
+			// resources, are still returned as JSON
+			config.ContentType = runtime.ContentTypeProtobuf
+			config.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+		case "json":
+			config.ContentType = runtime.ContentTypeJSON
+		default:
+			return fmt.Errorf("invalid content_type %q: must be \"json\" or \"protobuf\"", *k8sConfig.ContentType)
+		}
+	}
+
+	return nil
+}
+
+// throttleLogger wraps the client-side rate limiter and logs requests which
+// are held back by it, so slow queries can be attributed to qps/burst limits
+type throttleLogger struct {
+	flowcontrol.RateLimiter
+	logger hclog.Logger
+	host   string
+}
+
+func (t *throttleLogger) Accept() {
+	start := time.Now()
+	t.RateLimiter.Accept()
+	t.logWait(time.Since(start))
+}
+
+func (t *throttleLogger) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := t.RateLimiter.Wait(ctx)
+	t.logWait(time.Since(start))
+	return err
+}
+
+func (t *throttleLogger) logWait(wait time.Duration) {
+	if wait < throttleLogThreshold {
+		return
+	}
+	t.logger.Warn("client-side throttling delayed request, consider raising qps and burst", "host", t.host, "wait", wait, "qps", t.QPS())
+}
@@ -0,0 +1,137 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// benchmarkPodListSize is the number of pods in the list the content type
+// benchmarks encode and decode, one page at the default page_size
+const benchmarkPodListSize = defaultPageSize
+
+// generatePodList returns a list of n pods shaped like those of a typical
+// deployment, with labels, an owner, a container with resources and a status
+func generatePodList(n int) *corev1.PodList {
+	list := &corev1.PodList{
+		TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+		ListMeta: metav1.ListMeta{ResourceVersion: "123456"},
+	}
+	started := metav1.Now()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("web-7d4b9c8f6d-%05d", i)
+		list.Items = append(list.Items, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "payments",
+				UID:               types.UID(fmt.Sprintf("0b5c6a3e-1f2d-4c8b-9a7e-%012d", i)),
+				ResourceVersion:   fmt.Sprintf("%d", 100000+i),
+				CreationTimestamp: started,
+				Labels: map[string]string{
+					"app.kubernetes.io/name": "web",
+					"pod-template-hash":      "7d4b9c8f6d",
+					"tier":                   "frontend",
+				},
+				Annotations: map[string]string{
+					"prometheus.io/scrape": "true",
+					"prometheus.io/port":   "9090",
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1",
+					Kind:       "ReplicaSet",
+					Name:       "web-7d4b9c8f6d",
+					UID:        "5f1e2d3c-4b5a-6978-8a9b-0c1d2e3f4a5b",
+				}},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:           fmt.Sprintf("ip-10-0-%d-%d.ec2.internal", i/250, i%250),
+				ServiceAccountName: "web",
+				Containers: []corev1.Container{{
+					Name:  "web",
+					Image: "registry.example.com:5000/team/web:1.4.2",
+					Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}},
+					Env: []corev1.EnvVar{
+						{Name: "LOG_LEVEL", Value: "info"},
+						{Name: "PORT", Value: "8080"},
+					},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("250m"),
+							corev1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{
+				Phase:     corev1.PodRunning,
+				HostIP:    fmt.Sprintf("10.0.%d.%d", i/250, i%250),
+				PodIP:     fmt.Sprintf("10.1.%d.%d", i/250, i%250),
+				StartTime: &started,
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: started},
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: started},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:        "web",
+					Ready:       true,
+					Image:       "registry.example.com:5000/team/web:1.4.2",
+					ImageID:     "registry.example.com:5000/team/web@sha256:4c3f1e0b2a9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b",
+					ContainerID: fmt.Sprintf("containerd://%064d", i),
+					State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+				}},
+			},
+		})
+	}
+	return list
+}
+
+// podListSerializer returns the serializer the client uses for contentType
+func podListSerializer(b *testing.B, contentType string) runtime.Serializer {
+	info, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), contentType)
+	if !ok {
+		b.Fatalf("no serializer for %s", contentType)
+	}
+	return info.Serializer
+}
+
+func benchmarkDecodePodList(b *testing.B, contentType string) {
+	serializer := podListSerializer(b, contentType)
+	codec := scheme.Codecs.EncoderForVersion(serializer, corev1.SchemeGroupVersion)
+	data, err := runtime.Encode(codec, generatePodList(benchmarkPodListSize))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var list corev1.PodList
+		if _, _, err := serializer.Decode(data, nil, &list); err != nil {
+			b.Fatal(err)
+		}
+		if len(list.Items) != benchmarkPodListSize {
+			b.Fatalf("decoded %d pods, want %d", len(list.Items), benchmarkPodListSize)
+		}
+	}
+	b.ReportMetric(float64(len(data)), "bytes/list")
+}
+
+// BenchmarkDecodePodList measures the size and decode cost of a page of pods
+// in each content_type:
+//
+//	go test ./k8s -run '^$' -bench DecodePodList -benchmem
+func BenchmarkDecodePodList(b *testing.B) {
+	b.Run("json", func(b *testing.B) { benchmarkDecodePodList(b, runtime.ContentTypeJSON) })
+	b.Run("protobuf", func(b *testing.B) { benchmarkDecodePodList(b, runtime.ContentTypeProtobuf) })
+}
@@ -13,6 +13,8 @@ var objectMetadataPrimaryColumns = []*plugin.Column{
 	{Name: "namespace", Type: proto.ColumnType_STRING, Description: "Namespace defines the space within which each name must be unique."},
 	{Name: "uid", Type: proto.ColumnType_STRING, Description: "UID is the unique in time and space value for this object."},
 	{Name: "cluster_name", Type: proto.ColumnType_STRING, Description: "The name of the cluster which the object belongs to."},
+	{Name: "context_name", Type: proto.ColumnType_STRING, Transform: transform.FromMatrixItem(matrixKeyContext), Description: "The name of the kubeconfig context the object was read from."},
+	{Name: "cluster_server", Type: proto.ColumnType_STRING, Transform: transform.FromMatrixItem(matrixKeyServer), Description: "The API server URL of the cluster the object was read from."},
 }
 
 var objectMetadataSecondaryColumns = []*plugin.Column{
@@ -35,27 +37,39 @@ var objectMetadataSecondaryColumns = []*plugin.Column{
 	{Name: "managed_fields", Type: proto.ColumnType_JSON, Description: "ManagedFields maps workflow-id and version to the set of fields that are managed by that workflow. This is mostly for internal housekeeping, and users typically shouldn't need to set or understand this field."},
 }
 
-// hhmmmmm... why arent these working??
-// I dont see the data in the raw response either...
-// var typeMetaColumns = []*plugin.Column{
-// 	{Name: "kind", Type: proto.ColumnType_STRING, Description: "Kind is a string value representing the REST resource this object represents."},
-// 	{Name: "api_version", Type: proto.ColumnType_STRING, Description: "APIVersion defines the versioned schema of this representation of an object."},
-// }
-
-// these are resource type specific, and we should "flatten" them...
-// SHould we include them raw as well??
-var specStatusColumns = []*plugin.Column{
-	{Name: "spec", Type: proto.ColumnType_JSON, Description: "Specification of the desired behavior of the namespace. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status."},
-	{Name: "status", Type: proto.ColumnType_JSON, Description: "Most recently observed status of the namespace. This data may not be up to date.  Populated by the system.  More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status."},
+// virtual columns which pass selectors through to list calls, e.g.
+// where label_selector = 'app.kubernetes.io/name=web,tier!=cache'
+var selectorColumns = []*plugin.Column{
+	{Name: "label_selector", Type: proto.ColumnType_STRING, Hydrate: getListSelectors, Transform: transform.FromField("LabelSelector").NullIfZero(), Description: "A label selector used to filter the objects, e.g. 'app=web,tier!=cache'. Only set when given in the query."},
+	{Name: "field_selector", Type: proto.ColumnType_STRING, Hydrate: getListSelectors, Transform: transform.FromField("FieldSelector").NullIfZero(), Description: "A field selector used to filter the objects, e.g. 'metadata.name!=web'. Only set when given in the query."},
 }
 
-// append the common aws columns for REGIONAL resources onto the column list
-func k8sCommonColumns(columns []*plugin.Column) []*plugin.Column {
+// the API server leaves TypeMeta empty on the items it returns, so these come
+// from the scheme rather than the object fields
+var typeMetaColumns = []*plugin.Column{
+	{Name: "kind", Type: proto.ColumnType_STRING, Transform: transform.FromP(typeMetaFromScheme, "kind"), Description: "Kind is a string value representing the REST resource this object represents."},
+	{Name: "api_version", Type: proto.ColumnType_STRING, Transform: transform.FromP(typeMetaFromScheme, "api_version"), Description: "APIVersion defines the versioned schema of this representation of an object."},
+}
+
+// specStatusColumns returns the spec and status of the object as JSON, for the
+// fields which are not flattened into columns of their own. resource is the
+// name of the resource in the descriptions, e.g. "replica set".
+func specStatusColumns(resource string) []*plugin.Column {
+	return []*plugin.Column{
+		{Name: "spec", Type: proto.ColumnType_JSON, Description: "Specification of the desired behavior of the " + resource + ". More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status."},
+		{Name: "status", Type: proto.ColumnType_JSON, Description: "Most recently observed status of the " + resource + ". This data may not be up to date.  Populated by the system.  More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status."},
+	}
+}
+
+// append the columns common to all resources onto the column list. resource
+// names the resource in the descriptions of the spec and status columns.
+func k8sCommonColumns(resource string, columns []*plugin.Column) []*plugin.Column {
 	allColumns := objectMetadataPrimaryColumns
 	allColumns = append(allColumns, columns...)
-	//allColumns = append(allColumns, typeMetaColumns...)
-	allColumns = append(allColumns, specStatusColumns...)
+	allColumns = append(allColumns, typeMetaColumns...)
+	allColumns = append(allColumns, specStatusColumns(resource)...)
 	allColumns = append(allColumns, objectMetadataSecondaryColumns...)
+	allColumns = append(allColumns, selectorColumns...)
 
 	return allColumns
 }
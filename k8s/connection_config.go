@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/schema"
+)
+
+type k8sConfig struct {
+	ConfigPath    *string  `cty:"config_path"`
+	ConfigContext *string  `cty:"config_context"`
+	Contexts      []string `cty:"contexts"`
+	Cluster       *string  `cty:"cluster"`
+	User          *string  `cty:"user"`
+	Namespace     *string  `cty:"namespace"`
+	Namespaces    []string `cty:"namespaces"`
+	ExecTimeout   *string  `cty:"exec_timeout"`
+	ExecEnv       []string `cty:"exec_env"`
+
+	Host                  *string `cty:"host"`
+	Token                 *string `cty:"token"`
+	TokenFile             *string `cty:"token_file"`
+	ClientCertificate     *string `cty:"client_certificate"`
+	ClientKey             *string `cty:"client_key"`
+	ClusterCACertificate  *string `cty:"cluster_ca_certificate"`
+	InsecureSkipTLSVerify *bool   `cty:"insecure_skip_tls_verify"`
+
+	QPS            *float64 `cty:"qps"`
+	Burst          *int     `cty:"burst"`
+	RequestTimeout *string  `cty:"request_timeout"`
+	ProxyURL       *string  `cty:"proxy_url"`
+	PageSize       *int     `cty:"page_size"`
+	ContentType    *string  `cty:"content_type"`
+
+	InformerCache    *bool   `cty:"informer_cache"`
+	InformerCacheTTL *string `cty:"informer_cache_ttl"`
+}
+
+var ConfigSchema = map[string]*schema.Attribute{
+	"config_path": {
+		Type: schema.TypeString,
+	},
+	"config_context": {
+		Type: schema.TypeString,
+	},
+	"contexts": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
+	"cluster": {
+		Type: schema.TypeString,
+	},
+	"user": {
+		Type: schema.TypeString,
+	},
+	"namespace": {
+		Type: schema.TypeString,
+	},
+	"namespaces": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
+	"exec_timeout": {
+		Type: schema.TypeString,
+	},
+	"exec_env": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
+	"host": {
+		Type: schema.TypeString,
+	},
+	"token": {
+		Type: schema.TypeString,
+	},
+	"token_file": {
+		Type: schema.TypeString,
+	},
+	"client_certificate": {
+		Type: schema.TypeString,
+	},
+	"client_key": {
+		Type: schema.TypeString,
+	},
+	"cluster_ca_certificate": {
+		Type: schema.TypeString,
+	},
+	"insecure_skip_tls_verify": {
+		Type: schema.TypeBool,
+	},
+	"qps": {
+		Type: schema.TypeFloat,
+	},
+	"burst": {
+		Type: schema.TypeInt,
+	},
+	"request_timeout": {
+		Type: schema.TypeString,
+	},
+	"proxy_url": {
+		Type: schema.TypeString,
+	},
+	"page_size": {
+		Type: schema.TypeInt,
+	},
+	"content_type": {
+		Type: schema.TypeString,
+	},
+	"informer_cache": {
+		Type: schema.TypeBool,
+	},
+	"informer_cache_ttl": {
+		Type: schema.TypeString,
+	},
+}
+
+func ConfigInstance() interface{} {
+	return &k8sConfig{}
+}
+
+// GetConfig :: retrieve and cast connection config from query data
+func GetConfig(connection *plugin.Connection) k8sConfig {
+	if connection == nil || connection.Config == nil {
+		return k8sConfig{}
+	}
+	config, _ := connection.Config.(k8sConfig)
+	return config
+}
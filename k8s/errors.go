@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+// steps of building a clientset, used to report where a connection failed
+const (
+	stepLoadConfig      = "load kubeconfig"
+	stepConfigureAuth   = "configure authentication"
+	stepCreateClientset = "create clientset"
+	stepConnect         = "connect to cluster"
+)
+
+// connectionError is returned by GetNewClientset when the plugin cannot build
+// a working client. It names the kubeconfig and context in use and the step
+// that failed, so that the message is actionable on its own.
+type connectionError struct {
+	Step       string
+	ConfigPath string
+	Context    string
+	Server     string
+	// Reason is a short classification of Err, e.g. "cluster unreachable"
+	Reason string
+	Err    error
+}
+
+func (e *connectionError) Error() string {
+	var source []string
+	if e.ConfigPath != "" {
+		source = append(source, fmt.Sprintf("kubeconfig %q", e.ConfigPath))
+	}
+	if e.Context != "" {
+		source = append(source, fmt.Sprintf("context %q", e.Context))
+	}
+	if len(source) == 0 && e.Server != "" {
+		source = append(source, fmt.Sprintf("server %q", e.Server))
+	}
+
+	msg := fmt.Sprintf("failed to %s", e.Step)
+	if len(source) > 0 {
+		msg = fmt.Sprintf("%s (%s)", msg, strings.Join(source, ", "))
+	}
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Reason)
+	}
+	return fmt.Sprintf("%s: %v", msg, e.Err)
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.Err
+}
+
+func newConnectionError(step string, source clientSource, err error) *connectionError {
+	return &connectionError{
+		Step:       step,
+		ConfigPath: source.ConfigPath,
+		Context:    source.Context,
+		Server:     source.Server,
+		Reason:     connectionErrorReason(err),
+		Err:        err,
+	}
+}
+
+// connectionErrorReason classifies an error returned while talking to the
+// API server into the handful of causes users can act on
+func connectionErrorReason(err error) string {
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certInvalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return "unauthorized, check the credentials of the kubeconfig user"
+	case apierrors.IsForbidden(err):
+		return "forbidden, the kubeconfig user lacks permission"
+	case errors.As(err, &unknownAuthorityErr), errors.As(err, &certInvalidErr), errors.As(err, &hostnameErr):
+		return "certificate invalid, check the cluster certificate authority and server address"
+	case errors.As(err, &opErr), errors.As(err, &dnsErr), errors.As(err, &netErr) && netErr.Timeout():
+		return "cluster unreachable, check the server address and network access"
+	}
+	return ""
+}
+
+// isNotFoundError is used as the ShouldIgnoreError predicate for get calls, so
+// that a get for an object which does not exist returns no row
+func isNotFoundError(err error) bool {
+	return apierrors.IsNotFound(err)
+}
+
+// isRetryableError returns true for errors caused by throttling or a transient
+// API server problem, which may succeed if the request is repeated
+func isRetryableError(err error) bool {
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsUnexpectedServerError(err)
+}
+
+// retry settings for throttled and transient API server errors
+const (
+	maxRetries        = 4
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
+// retryTransientErrors calls fn, repeating it with exponential backoff while it
+// returns retryable errors. If the API server suggests a delay (Retry-After),
+// we wait at least that long.
+func retryTransientErrors(ctx context.Context, fn func() error) error {
+	logger := plugin.Logger(ctx)
+
+	delay := initialRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == maxRetries || !isRetryableError(err) {
+			return err
+		}
+
+		wait := delay
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+		logger.Warn("retryTransientErrors retrying request", "attempt", attempt+1, "wait", wait, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"strings"
+)
+
+const (
+	// dockerHubRegistry is the registry of images named without one, e.g. nginx
+	dockerHubRegistry = "docker.io"
+	// dockerHubOfficialNamespace holds Docker Hub's official images, which are
+	// named without a namespace, e.g. nginx is docker.io/library/nginx
+	dockerHubOfficialNamespace = "library"
+	latestTag                  = "latest"
+)
+
+// imageReference is a container image reference, such as
+// registry.example.com:5000/team/app:1.2@sha256:..., split into its parts and
+// normalised as docker and the kubelet do, so nginx has the registry docker.io
+// and the repository library/nginx
+type imageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+	// Normalized is the reference with the registry and repository written
+	// out in full, e.g. docker.io/library/nginx:1.19
+	Normalized string
+	// IsLatestOrUntagged is true if the reference has the tag latest, or has
+	// neither a tag nor a digest and so pulls latest
+	IsLatestOrUntagged bool
+}
+
+// parseImageReference splits image into its registry, repository, tag and
+// digest. It does not validate the reference, as the API server accepts any
+// image string and we would rather show the parts than nothing.
+func parseImageReference(image string) imageReference {
+	var ref imageReference
+	if image == "" {
+		return ref
+	}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+
+	// a tag follows the last colon after the last slash; an earlier colon
+	// separates a registry host from its port
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+
+	// the first component is a registry if it looks like a host name
+	ref.Registry, ref.Repository = dockerHubRegistry, name
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, ref.Repository = host, name[i+1:]
+		}
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = dockerHubRegistry
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = dockerHubOfficialNamespace + "/" + ref.Repository
+	}
+
+	ref.Normalized = ref.Registry + "/" + ref.Repository
+	if ref.Tag != "" {
+		ref.Normalized += ":" + ref.Tag
+	}
+	if ref.Digest != "" {
+		ref.Normalized += "@" + ref.Digest
+	}
+
+	ref.IsLatestOrUntagged = ref.Tag == latestTag || (ref.Tag == "" && ref.Digest == "")
+
+	return ref
+}
+
+// imageIDDigest returns the digest of the image a container is running from
+// its status imageID, e.g. docker-pullable://nginx@sha256:..., or "" if the
+// container runtime reported only a local image ID
+func imageIDDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return ""
+}
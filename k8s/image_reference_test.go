@@ -0,0 +1,143 @@
+package k8s
+
+import "testing"
+
+const testDigest = "sha256:4c3f1e0b2a9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b"
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  imageReference
+	}{
+		{
+			image: "",
+			want:  imageReference{},
+		},
+		{
+			image: "nginx",
+			want: imageReference{
+				Registry:           "docker.io",
+				Repository:         "library/nginx",
+				Normalized:         "docker.io/library/nginx",
+				IsLatestOrUntagged: true,
+			},
+		},
+		{
+			image: "nginx:1.19",
+			want: imageReference{
+				Registry:   "docker.io",
+				Repository: "library/nginx",
+				Tag:        "1.19",
+				Normalized: "docker.io/library/nginx:1.19",
+			},
+		},
+		{
+			image: "docker.io/nginx",
+			want: imageReference{
+				Registry:           "docker.io",
+				Repository:         "library/nginx",
+				Normalized:         "docker.io/library/nginx",
+				IsLatestOrUntagged: true,
+			},
+		},
+		{
+			image: "index.docker.io/library/nginx:latest",
+			want: imageReference{
+				Registry:           "docker.io",
+				Repository:         "library/nginx",
+				Tag:                "latest",
+				Normalized:         "docker.io/library/nginx:latest",
+				IsLatestOrUntagged: true,
+			},
+		},
+		{
+			image: "bitnami/redis:6.0",
+			want: imageReference{
+				Registry:   "docker.io",
+				Repository: "bitnami/redis",
+				Tag:        "6.0",
+				Normalized: "docker.io/bitnami/redis:6.0",
+			},
+		},
+		{
+			image: "localhost:5000/a",
+			want: imageReference{
+				Registry:           "localhost:5000",
+				Repository:         "a",
+				Normalized:         "localhost:5000/a",
+				IsLatestOrUntagged: true,
+			},
+		},
+		{
+			image: "localhost/a:dev",
+			want: imageReference{
+				Registry:   "localhost",
+				Repository: "a",
+				Tag:        "dev",
+				Normalized: "localhost/a:dev",
+			},
+		},
+		{
+			image: "registry.example.com:5000/team/app@" + testDigest,
+			want: imageReference{
+				Registry:   "registry.example.com:5000",
+				Repository: "team/app",
+				Digest:     testDigest,
+				Normalized: "registry.example.com:5000/team/app@" + testDigest,
+			},
+		},
+		{
+			image: "registry.example.com:5000/team/app:1.2@" + testDigest,
+			want: imageReference{
+				Registry:   "registry.example.com:5000",
+				Repository: "team/app",
+				Tag:        "1.2",
+				Digest:     testDigest,
+				Normalized: "registry.example.com:5000/team/app:1.2@" + testDigest,
+			},
+		},
+		{
+			// the digest pins the image, but the reference still names latest
+			image: "app:latest@" + testDigest,
+			want: imageReference{
+				Registry:           "docker.io",
+				Repository:         "library/app",
+				Tag:                "latest",
+				Digest:             testDigest,
+				Normalized:         "docker.io/library/app:latest@" + testDigest,
+				IsLatestOrUntagged: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.image, func(t *testing.T) {
+			if got := parseImageReference(test.image); got != test.want {
+				t.Errorf("parseImageReference(%q) = %+v, want %+v", test.image, got, test.want)
+			}
+		})
+	}
+}
+
+func TestImageIDDigest(t *testing.T) {
+	tests := []struct {
+		imageID string
+		want    string
+	}{
+		{imageID: "", want: ""},
+		{imageID: "docker-pullable://nginx@" + testDigest, want: testDigest},
+		{imageID: "docker.io/library/nginx@" + testDigest, want: testDigest},
+		{imageID: "registry.example.com:5000/team/app@" + testDigest, want: testDigest},
+		// containerd reports only the local image ID for images it did not pull
+		{imageID: testDigest, want: ""},
+		{imageID: "docker://" + testDigest, want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.imageID, func(t *testing.T) {
+			if got := imageIDDigest(test.imageID); got != test.want {
+				t.Errorf("imageIDDigest(%q) = %q, want %q", test.imageID, got, test.want)
+			}
+		})
+	}
+}
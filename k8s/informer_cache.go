@@ -0,0 +1,449 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+// defaultInformerCacheTTL is how long an informer is kept running after it was
+// last used, when the connection does not set informer_cache_ttl
+const defaultInformerCacheTTL = 5 * time.Minute
+
+// informerSyncTimeout bounds how long queries wait for the initial list an
+// informer makes before it can serve them. Queries fall back to the API while
+// the informer carries on syncing in the background.
+const informerSyncTimeout = 30 * time.Second
+
+// labelIndex is the name of the indexer keying objects by each of their
+// labels, as key=value
+const labelIndex = "label"
+
+// informerCacheKey identifies an informer: the resource it watches in the
+// cluster a clientset connects to
+type informerCacheKey struct {
+	Client   string
+	Resource schema.GroupVersionResource
+}
+
+// informerCaches holds the running informers, shared by every query the
+// plugin serves, and the time until which informers we may not run, as the
+// user may not list or watch the resource across the cluster, are not retried
+var informerCaches = struct {
+	sync.Mutex
+	entries   map[informerCacheKey]*informerCache
+	forbidden map[informerCacheKey]time.Time
+}{
+	entries:   map[informerCacheKey]*informerCache{},
+	forbidden: map[informerCacheKey]time.Time{},
+}
+
+// informerCache is a shared informer for one resource in one cluster. It
+// keeps a local copy of every object of the resource, indexed by namespace and
+// label, up to date with a watch.
+type informerCache struct {
+	informer cache.SharedIndexInformer
+	stop     chan struct{}
+	stopOnce sync.Once
+	// forbidden is closed if the API server refuses the informer's list or
+	// watch, so that a query waiting for it to sync can fall back at once
+	forbidden     chan struct{}
+	forbiddenOnce sync.Once
+	// started is when the informer was started, from which queries wait
+	// informerSyncTimeout for it to sync
+	started time.Time
+
+	mu       sync.Mutex
+	lastUsed time.Time
+}
+
+// listFromInformerCache streams the objects of resource matching the query
+// from the informer cache, if the connection enables informer_cache. served
+// is false if the query must be answered by the API server instead: when the
+// cache is disabled, the query gives an explicit field_selector, or the
+// informer has not synced yet.
+//
+// Items streamed are shared with the cache, so hydrates must not modify them.
+func listFromInformerCache(ctx context.Context, d *plugin.QueryData, resource schema.GroupVersionResource, namespaced bool, opts metav1.ListOptions) (served bool, err error) {
+	return eachInformerCacheItem(ctx, d, resource, namespaced, opts, func(item interface{}) {
+		d.StreamListItem(ctx, item)
+	})
+}
+
+// eachInformerCacheItem passes the objects listFromInformerCache would stream
+// to fn instead
+func eachInformerCacheItem(ctx context.Context, d *plugin.QueryData, resource schema.GroupVersionResource, namespaced bool, opts metav1.ListOptions, fn func(item interface{})) (served bool, err error) {
+	// field selectors pushed down from quals are only an optimisation, as
+	// Postgres checks those quals itself, but an explicit field_selector
+	// cannot be evaluated locally
+	if getSelectorQuals(d).FieldSelector != "" {
+		return false, nil
+	}
+
+	entry, err := getInformerCache(ctx, d, resource)
+	if entry == nil || err != nil {
+		return err != nil, err
+	}
+
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return true, err
+	}
+
+	namespaces := []string{metav1.NamespaceAll}
+	if namespaced {
+		namespaces = getListNamespaces(d)
+	}
+
+	items, err := entry.list(namespaces, selector)
+	if err != nil {
+		return true, err
+	}
+	for _, item := range items {
+		fn(item)
+	}
+	return true, nil
+}
+
+// getFromInformerCache returns the object of resource with the given
+// namespace and name from the informer cache, if the connection enables
+// informer_cache. As with a get call, a missing object is not an error and
+// returns nil. served is false if the query must be answered by the API
+// server instead.
+func getFromInformerCache(ctx context.Context, d *plugin.QueryData, resource schema.GroupVersionResource, namespace string, name string) (item interface{}, served bool, err error) {
+	entry, err := getInformerCache(ctx, d, resource)
+	if entry == nil || err != nil {
+		return nil, err != nil, err
+	}
+
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	item, exists, err := entry.informer.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		return nil, true, err
+	}
+	return item, true, nil
+}
+
+// getInformerCache returns the synced informer for resource in the cluster of
+// the current matrix item, starting it if this is its first use. It returns
+// nil if the connection does not enable informer_cache, or if the informer
+// has not synced in time, in which case the caller should use the API.
+func getInformerCache(ctx context.Context, d *plugin.QueryData, resource schema.GroupVersionResource) (*informerCache, error) {
+	logger := plugin.Logger(ctx)
+
+	k8sConfig := GetConfig(d.Connection)
+	if k8sConfig.InformerCache == nil || !*k8sConfig.InformerCache {
+		return nil, nil
+	}
+
+	ttl, err := getInformerCacheTTL(k8sConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	_, clientKey, err := getClientset(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	key := informerCacheKey{Client: clientKey, Resource: resource}
+
+	informerCaches.Lock()
+	entry, ok := informerCaches.entries[key]
+	forbiddenUntil, forbidden := informerCaches.forbidden[key]
+	informerCaches.Unlock()
+
+	if !ok {
+		if forbidden && time.Now().Before(forbiddenUntil) {
+			return nil, nil
+		}
+
+		// the reflector retries a forbidden list until we give up waiting,
+		// and reports it without its status, so check it may list up front
+		if forbidden, err := informerListForbidden(ctx, d, resource); forbidden {
+			logger.Warn("getInformerCache may not list across the cluster, falling back to the API", "resource", resource.String(), "error", err)
+			rememberInformerForbidden(key, ttl)
+			return nil, nil
+		}
+
+		entry, err = startInformerCache(ctx, d, key, ttl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	entry.touch()
+
+	// on a large cluster the first sync may take longer than a query should
+	// wait, so later queries only wait out what is left of the first wait
+	syncCtx, cancel := context.WithDeadline(ctx, entry.started.Add(informerSyncTimeout))
+	defer cancel()
+	syncStop := make(chan struct{})
+	go func() {
+		select {
+		case <-syncCtx.Done():
+		case <-entry.forbidden:
+		}
+		close(syncStop)
+	}()
+	if !cache.WaitForCacheSync(syncStop, entry.informer.HasSynced) {
+		select {
+		case <-entry.forbidden:
+			// the watch error handler has already stopped the informer
+			logger.Warn("getInformerCache may not watch across the cluster, falling back to the API", "resource", resource.String())
+			return nil, nil
+		default:
+		}
+		// the informer keeps syncing, and is stopped by expireWhenIdle if
+		// queries stop using it before it is ready
+		if ctx.Err() == nil {
+			logger.Warn("getInformerCache informer has not synced yet, falling back to the API", "resource", resource.String())
+		}
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+// startInformerCache starts the informer for key, unless another query has
+// already started it, and returns it
+func startInformerCache(ctx context.Context, d *plugin.QueryData, key informerCacheKey, ttl time.Duration) (*informerCache, error) {
+	logger := plugin.Logger(ctx)
+
+	config, source, _, err := getClientConfig(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	// request_timeout applies to whole requests, so it would cut every watch
+	// short; the reflector already bounds its watches itself
+	informerConfig := rest.CopyConfig(config)
+	informerConfig.Timeout = 0
+	clientset, err := kubernetes.NewForConfig(informerConfig)
+	if err != nil {
+		return nil, newConnectionError(stepCreateClientset, source, err)
+	}
+
+	informerCaches.Lock()
+	defer informerCaches.Unlock()
+
+	if entry, ok := informerCaches.entries[key]; ok {
+		return entry, nil
+	}
+
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	genericInformer, err := factory.ForResource(key.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &informerCache{
+		informer:  genericInformer.Informer(),
+		stop:      make(chan struct{}),
+		forbidden: make(chan struct{}),
+		started:   time.Now(),
+		lastUsed:  time.Now(),
+	}
+	if err := entry.informer.AddIndexers(cache.Indexers{labelIndex: labelIndexFunc}); err != nil {
+		return nil, err
+	}
+	// the reflector retries a forbidden watch forever, so stop the informer
+	// and keep later queries on the API until the TTL has passed
+	err = entry.informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		if !apierrors.IsForbidden(err) {
+			cache.DefaultWatchErrorHandler(r, err)
+			return
+		}
+		logger.Warn("getInformerCache watch forbidden, stopping informer", "resource", key.Resource.String(), "error", err)
+		rememberInformerForbidden(key, ttl)
+		removeInformerCache(key, entry)
+		entry.forbiddenOnce.Do(func() { close(entry.forbidden) })
+	})
+	if err != nil {
+		return nil, err
+	}
+	informerCaches.entries[key] = entry
+	delete(informerCaches.forbidden, key)
+
+	logger.Info("getInformerCache starting informer", "resource", key.Resource.String(), "ttl", ttl)
+	factory.Start(entry.stop)
+	go entry.expireWhenIdle(key, ttl)
+
+	return entry, nil
+}
+
+// informerListForbidden returns true, with the error, if the API server
+// refuses to list resource across the whole cluster
+func informerListForbidden(ctx context.Context, d *plugin.QueryData, resource schema.GroupVersionResource) (bool, error) {
+	metadataClient, err := getMetadataClient(ctx, d)
+	if err != nil {
+		return false, err
+	}
+	_, err = metadataClient.Resource(resource).List(ctx, metav1.ListOptions{Limit: 1})
+	return apierrors.IsForbidden(err), err
+}
+
+// rememberInformerForbidden records that the informer for key may not run, so
+// that queries use the API without retrying it until ttl has passed
+func rememberInformerForbidden(key informerCacheKey, ttl time.Duration) {
+	informerCaches.Lock()
+	defer informerCaches.Unlock()
+	informerCaches.forbidden[key] = time.Now().Add(ttl)
+}
+
+// list returns the cached objects in any of namespaces (where
+// metav1.NamespaceAll matches every namespace) matching selector. If the
+// selector requires a label value, the label index narrows the search.
+func (c *informerCache) list(namespaces []string, selector labels.Selector) ([]interface{}, error) {
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	indexer := c.informer.GetIndexer()
+
+	if indexKey, ok := labelIndexKey(selector); ok {
+		candidates, err := indexer.ByIndex(labelIndex, indexKey)
+		if err != nil {
+			return nil, err
+		}
+		return filterObjects(candidates, namespaces, selector)
+	}
+
+	var items []interface{}
+	for _, namespace := range namespaces {
+		if namespace == metav1.NamespaceAll {
+			return filterObjects(indexer.List(), nil, selector)
+		}
+		candidates, err := indexer.ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, err
+		}
+		matching, err := filterObjects(candidates, nil, selector)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, matching...)
+	}
+	return items, nil
+}
+
+// touch records that the informer was used by a query
+func (c *informerCache) touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastUsed = time.Now()
+}
+
+func (c *informerCache) idleTime() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.lastUsed)
+}
+
+// expireWhenIdle stops the informer once no query has used it for ttl
+func (c *informerCache) expireWhenIdle(key informerCacheKey, ttl time.Duration) {
+	for {
+		idle := c.idleTime()
+		if idle >= ttl {
+			removeInformerCache(key, c)
+			return
+		}
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(ttl - idle):
+		}
+	}
+}
+
+// removeInformerCache stops the informer and removes it from the shared
+// informers, unless it has already been replaced
+func removeInformerCache(key informerCacheKey, c *informerCache) {
+	informerCaches.Lock()
+	if informerCaches.entries[key] == c {
+		delete(informerCaches.entries, key)
+	}
+	informerCaches.Unlock()
+
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// getInformerCacheTTL returns how long informers are kept running after their
+// last use
+func getInformerCacheTTL(k8sConfig k8sConfig) (time.Duration, error) {
+	if k8sConfig.InformerCacheTTL == nil {
+		return defaultInformerCacheTTL, nil
+	}
+	return parsePositiveDuration("informer_cache_ttl", *k8sConfig.InformerCacheTTL)
+}
+
+// labelIndexFunc indexes an object by each of its labels, as key=value
+func labelIndexFunc(obj interface{}) ([]string, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key, value := range object.GetLabels() {
+		keys = append(keys, key+"="+value)
+	}
+	return keys, nil
+}
+
+// labelIndexKey returns a label index key for the first requirement of
+// selector which requires a single label value
+func labelIndexKey(selector labels.Selector) (string, bool) {
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if values := requirement.Values(); values.Len() == 1 {
+				return requirement.Key() + "=" + values.List()[0], true
+			}
+		}
+	}
+	return "", false
+}
+
+// filterObjects returns the objects in any of namespaces which match selector.
+// A nil namespaces matches every namespace.
+func filterObjects(objects []interface{}, namespaces []string, selector labels.Selector) ([]interface{}, error) {
+	inNamespace := map[string]bool{}
+	for _, namespace := range namespaces {
+		if namespace == metav1.NamespaceAll {
+			namespaces = nil
+			break
+		}
+		inNamespace[namespace] = true
+	}
+
+	var matching []interface{}
+	for _, obj := range objects {
+		object, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if namespaces != nil && !inNamespace[object.GetNamespace()] {
+			continue
+		}
+		if !selector.Matches(labels.Set(object.GetLabels())) {
+			continue
+		}
+		matching = append(matching, obj)
+	}
+	return matching, nil
+}
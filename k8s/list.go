@@ -0,0 +1,263 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+// defaultPageSize is the number of items requested per list call when the
+// connection does not set page_size
+const defaultPageSize = 500
+
+// maxListRestarts is how many times a paged list is restarted after its
+// continue token expires, before it is made as a single unpaged list instead
+const maxListRestarts = 2
+
+// listPageFunc fetches a single page of a list call, e.g.
+// clientset.CoreV1().Pods(namespace).List
+type listPageFunc func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error)
+
+// streamList pages through a list call using Limit and Continue, streaming the
+// items of each page as it arrives rather than holding the whole list in
+// memory. If the continue token expires part way through (410 Gone), the list
+// is restarted from the beginning and items already streamed are skipped. If
+// it keeps expiring, as when the consumer is slower than the etcd compaction
+// window, the list is made in a single unpaged call instead.
+func streamList(ctx context.Context, d *plugin.QueryData, opts metav1.ListOptions, listPage listPageFunc) error {
+	return streamPages(ctx, d, opts, listPage, func(item metav1.Object) {
+		d.StreamListItem(ctx, item)
+	})
+}
+
+// streamPages pages through a list call as streamList does, passing each item
+// to fn
+func streamPages(ctx context.Context, d *plugin.QueryData, opts metav1.ListOptions, listPage listPageFunc, fn func(item metav1.Object)) error {
+	logger := plugin.Logger(ctx)
+
+	opts.Limit = getPageSize(d)
+	streamed := map[types.UID]bool{}
+	restarts := 0
+
+	for {
+		var page runtime.Object
+		err := retryTransientErrors(ctx, func() (err error) {
+			page, err = listPage(ctx, opts)
+			return err
+		})
+		if err != nil {
+			if opts.Continue != "" && apierrors.IsResourceExpired(err) {
+				opts.Continue = ""
+				restarts++
+				if restarts > maxListRestarts {
+					logger.Warn("streamPages continue token expired repeatedly, listing without paging", "restarts", restarts-1, "error", err)
+					opts.Limit = 0
+				} else {
+					logger.Warn("streamPages continue token expired, restarting list", "error", err)
+				}
+				continue
+			}
+			return err
+		}
+
+		err = meta.EachListItem(page, func(item runtime.Object) error {
+			object, err := meta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			if streamed[object.GetUID()] {
+				return nil
+			}
+			streamed[object.GetUID()] = true
+			fn(object)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		listMeta, err := meta.ListAccessor(page)
+		if err != nil {
+			return err
+		}
+		if listMeta.GetContinue() == "" {
+			return nil
+		}
+		opts.Continue = listMeta.GetContinue()
+	}
+}
+
+// namespacedListPageFunc fetches a single page of a list call in namespace,
+// e.g. clientset.CoreV1().Pods(namespace).List
+type namespacedListPageFunc func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error)
+
+// streamNamespacedList streams a list of namespaced objects. If the query
+// restricts the namespace column (namespace = 'x' or namespace in (...)), or
+// the connection sets namespace, the list calls are scoped to those
+// namespaces, concurrently. Otherwise a single list is made across all
+// namespaces, and if that is forbidden, each namespace the user may be able to
+// access is listed instead, skipping those which are also forbidden.
+func streamNamespacedList(ctx context.Context, d *plugin.QueryData, opts metav1.ListOptions, listPage namespacedListPageFunc) error {
+	return eachNamespacedListItem(ctx, d, opts, listPage, func(item metav1.Object) {
+		d.StreamListItem(ctx, item)
+	})
+}
+
+// eachNamespacedListItem lists namespaced objects as streamNamespacedList
+// does, passing each item to fn. fn may be called concurrently.
+func eachNamespacedListItem(ctx context.Context, d *plugin.QueryData, opts metav1.ListOptions, listPage namespacedListPageFunc, fn func(item metav1.Object)) error {
+	logger := plugin.Logger(ctx)
+
+	namespaces := getListNamespaces(d)
+	if len(namespaces) != 1 || namespaces[0] != metav1.NamespaceAll {
+		return streamNamespaces(ctx, d, namespaces, opts, listPage, false, fn)
+	}
+
+	err := streamPages(ctx, d, opts, func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return listPage(ctx, metav1.NamespaceAll, opts)
+	}, fn)
+	if !apierrors.IsForbidden(err) {
+		return err
+	}
+
+	namespaces, fallbackErr := getFallbackNamespaces(ctx, d)
+	if fallbackErr != nil {
+		logger.Warn("streamNamespacedList cannot determine namespaces to fall back to", "error", fallbackErr)
+		return fmt.Errorf("%w: set the namespaces connection argument to the namespaces you can access", err)
+	}
+	logger.Info("streamNamespacedList cluster-wide list forbidden, listing each namespace", "namespaces", namespaces)
+	return streamNamespaces(ctx, d, namespaces, opts, listPage, true, fn)
+}
+
+// streamNamespaces lists each of namespaces concurrently, passing each item to
+// fn. If skipForbidden is set, namespaces the user may not list are logged and
+// skipped.
+func streamNamespaces(ctx context.Context, d *plugin.QueryData, namespaces []string, opts metav1.ListOptions, listPage namespacedListPageFunc, skipForbidden bool, fn func(item metav1.Object)) error {
+	logger := plugin.Logger(ctx)
+
+	var wg sync.WaitGroup
+	errorChan := make(chan error, len(namespaces))
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		go func(namespace string) {
+			defer wg.Done()
+			err := streamPages(ctx, d, opts, func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return listPage(ctx, namespace, opts)
+			}, fn)
+			if skipForbidden && apierrors.IsForbidden(err) {
+				logger.Warn("streamNamespaces skipping forbidden namespace", "namespace", namespace)
+				return
+			}
+			if err != nil {
+				errorChan <- err
+			}
+		}(namespace)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errorChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+// getFallbackNamespaces returns the namespaces to list one by one when the user
+// cannot list across all namespaces: those in the namespaces connection
+// argument, or else every namespace in the cluster, if the user may list them,
+// or else the namespace of the kubeconfig context
+func getFallbackNamespaces(ctx context.Context, d *plugin.QueryData) ([]string, error) {
+	logger := plugin.Logger(ctx)
+
+	k8sConfig := GetConfig(d.Connection)
+	if len(k8sConfig.Namespaces) > 0 {
+		return k8sConfig.Namespaces, nil
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	err = streamPages(ctx, d, metav1.ListOptions{}, func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, opts)
+	}, func(item metav1.Object) {
+		namespaces = append(namespaces, item.GetName())
+	})
+	if err == nil {
+		return namespaces, nil
+	}
+
+	// users with access to only some namespaces usually may not list
+	// namespaces either, but the context generally names one they can access
+	contextNamespace := getContextNamespace(ctx, d)
+	if contextNamespace == "" {
+		return nil, err
+	}
+	logger.Info("getFallbackNamespaces cannot list namespaces, using the context namespace", "namespace", contextNamespace, "error", err)
+	return []string{contextNamespace}, nil
+}
+
+// getContextNamespace returns the namespace connection argument, or else the
+// namespace of the kubeconfig context, or of the service account when running
+// in a pod. It returns "" if neither is known.
+func getContextNamespace(ctx context.Context, d *plugin.QueryData) string {
+	k8sConfig := getMatrixConfig(ctx, d)
+	if k8sConfig.Namespace != nil && *k8sConfig.Namespace != "" {
+		return *k8sConfig.Namespace
+	}
+
+	// only the namespace is needed, so an otherwise unusable config is ignored
+	_, source, _ := getRestConfig(ctx, k8sConfig)
+	return source.Namespace
+}
+
+// getListNamespaces returns the namespaces namespaced list calls should be
+// scoped to. A single empty string lists across all namespaces.
+func getListNamespaces(d *plugin.QueryData) []string {
+	k8sConfig := GetConfig(d.Connection)
+
+	namespaces, ok := getEqualsQualValues(d, "namespace")
+	if !ok {
+		if k8sConfig.Namespace != nil {
+			return []string{*k8sConfig.Namespace}
+		}
+		return []string{metav1.NamespaceAll}
+	}
+
+	// the connection namespace limits which of the requested namespaces we may list
+	if k8sConfig.Namespace != nil {
+		if helpers.StringSliceContains(namespaces, *k8sConfig.Namespace) {
+			return []string{*k8sConfig.Namespace}
+		}
+		return nil
+	}
+	return namespaces
+}
+
+// namespaceAllowed returns false if the connection sets namespace and it is
+// not namespace, so get calls are limited to the same namespace as lists
+func namespaceAllowed(d *plugin.QueryData, namespace string) bool {
+	k8sConfig := GetConfig(d.Connection)
+	return k8sConfig.Namespace == nil || *k8sConfig.Namespace == namespace
+}
+
+// getPageSize returns the number of items to request per list call
+func getPageSize(d *plugin.QueryData) int64 {
+	k8sConfig := GetConfig(d.Connection)
+	if k8sConfig.PageSize != nil {
+		return int64(*k8sConfig.PageSize)
+	}
+	return defaultPageSize
+}
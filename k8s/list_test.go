@@ -0,0 +1,170 @@
+package k8s
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/context_key"
+)
+
+var podsResource = schema.GroupResource{Resource: "pods"}
+
+func testListContext() context.Context {
+	return context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+}
+
+func testListQueryData(config k8sConfig) *plugin.QueryData {
+	return &plugin.QueryData{
+		Connection:   &plugin.Connection{Config: config},
+		QueryContext: &proto.QueryContext{},
+	}
+}
+
+// podList returns a list of pods with the given UIDs, continued by cont
+func podList(cont string, uids ...string) *corev1.PodList {
+	list := &corev1.PodList{ListMeta: metav1.ListMeta{Continue: cont}}
+	for _, uid := range uids {
+		list.Items = append(list.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: uid, UID: types.UID(uid)}})
+	}
+	return list
+}
+
+// collectUIDs returns a fn for listing which records the UID of each item it
+// is passed, and a func returning how many times each UID was passed
+func collectUIDs() (func(item metav1.Object), func() map[string]int) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	return func(item metav1.Object) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[string(item.GetUID())]++
+		}, func() map[string]int {
+			mu.Lock()
+			defer mu.Unlock()
+			return seen
+		}
+}
+
+func TestStreamPagesFallsBackToFullListWhenContinueKeepsExpiring(t *testing.T) {
+	var calls []metav1.ListOptions
+	listPage := func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		calls = append(calls, opts)
+		switch {
+		case opts.Limit == 0:
+			return podList("", "a", "b", "c", "d"), nil
+		case opts.Continue == "":
+			return podList("page-2", "a", "b"), nil
+		default:
+			return nil, apierrors.NewResourceExpired("continue token expired")
+		}
+	}
+
+	fn, seen := collectUIDs()
+	d := testListQueryData(k8sConfig{})
+	if err := streamPages(testListContext(), d, metav1.ListOptions{}, listPage, fn); err != nil {
+		t.Fatalf("streamPages returned error: %v", err)
+	}
+
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		if seen()[uid] != 1 {
+			t.Errorf("item %q passed to fn %d times, want 1", uid, seen()[uid])
+		}
+	}
+
+	// each restart fetches the first page and fails on the second
+	wantCalls := 2*(maxListRestarts+1) + 1
+	if len(calls) != wantCalls {
+		t.Fatalf("listPage called %d times, want %d", len(calls), wantCalls)
+	}
+	if last := calls[len(calls)-1]; last.Limit != 0 || last.Continue != "" {
+		t.Errorf("last list call had limit %d and continue %q, want an unpaged list", last.Limit, last.Continue)
+	}
+}
+
+func TestStreamPagesRestartsWhenContinueExpiresOnce(t *testing.T) {
+	expired := false
+	listPage := func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		switch opts.Continue {
+		case "":
+			return podList("page-2", "a", "b"), nil
+		default:
+			if !expired {
+				expired = true
+				return nil, apierrors.NewResourceExpired("continue token expired")
+			}
+			return podList("", "c"), nil
+		}
+	}
+
+	fn, seen := collectUIDs()
+	d := testListQueryData(k8sConfig{})
+	if err := streamPages(testListContext(), d, metav1.ListOptions{}, listPage, fn); err != nil {
+		t.Fatalf("streamPages returned error: %v", err)
+	}
+
+	want := map[string]int{"a": 1, "b": 1, "c": 1}
+	for uid, count := range want {
+		if seen()[uid] != count {
+			t.Errorf("item %q passed to fn %d times, want %d", uid, seen()[uid], count)
+		}
+	}
+}
+
+func TestEachNamespacedListItemFallsBackToNamespacesWhenForbidden(t *testing.T) {
+	listPage := func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		switch namespace {
+		case metav1.NamespaceAll, "team-b":
+			return nil, apierrors.NewForbidden(podsResource, "", nil)
+		case "team-a":
+			return podList("", "a1", "a2"), nil
+		case "team-c":
+			return podList("", "c1"), nil
+		default:
+			t.Errorf("unexpected list of namespace %q", namespace)
+			return podList(""), nil
+		}
+	}
+
+	fn, seen := collectUIDs()
+	d := testListQueryData(k8sConfig{Namespaces: []string{"team-a", "team-b", "team-c"}})
+	if err := eachNamespacedListItem(testListContext(), d, metav1.ListOptions{}, listPage, fn); err != nil {
+		t.Fatalf("eachNamespacedListItem returned error: %v", err)
+	}
+
+	var uids []string
+	for uid, count := range seen() {
+		if count != 1 {
+			t.Errorf("item %q passed to fn %d times, want 1", uid, count)
+		}
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	if got, want := len(uids), 3; got != want || uids[0] != "a1" || uids[1] != "a2" || uids[2] != "c1" {
+		t.Errorf("items passed to fn = %v, want [a1 a2 c1]", uids)
+	}
+}
+
+func TestEachNamespacedListItemReturnsOtherErrors(t *testing.T) {
+	listPage := func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return nil, apierrors.NewUnauthorized("token expired")
+	}
+
+	fn, _ := collectUIDs()
+	d := testListQueryData(k8sConfig{Namespaces: []string{"team-a"}})
+	err := eachNamespacedListItem(testListContext(), d, metav1.ListOptions{}, listPage, fn)
+	if !apierrors.IsUnauthorized(err) {
+		t.Errorf("eachNamespacedListItem returned %v, want an unauthorized error", err)
+	}
+}
@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+// listMetadataOnly streams the objects of resource using the metadata client,
+// which returns only their ObjectMeta, if every column the query requests can
+// be populated from it. This avoids fetching and decoding specs and statuses
+// for inventory queries. served is false if the query needs other columns, in
+// which case the caller should make the typed list call.
+func listMetadataOnly(ctx context.Context, d *plugin.QueryData, resource schema.GroupVersionResource, namespaced bool, opts metav1.ListOptions) (served bool, err error) {
+	if !metadataColumnsOnly(d) {
+		return false, nil
+	}
+
+	logger := plugin.Logger(ctx)
+	logger.Trace("listMetadataOnly", "resource", resource.String())
+
+	metadataClient, err := getMetadataClient(ctx, d)
+	if err != nil {
+		return true, err
+	}
+
+	if !namespaced {
+		return true, streamList(ctx, d, opts, func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			return metadataClient.Resource(resource).List(ctx, opts)
+		})
+	}
+	return true, streamNamespacedList(ctx, d, opts, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return metadataClient.Resource(resource).Namespace(namespace).List(ctx, opts)
+	})
+}
+
+// metadataColumnsOnly returns true if every column the query requests is one
+// of the object metadata or selector columns common to all tables. The raw
+// column holds the whole object, so it is not a metadata column.
+func metadataColumnsOnly(d *plugin.QueryData) bool {
+	if len(d.QueryContext.Columns) == 0 {
+		return false
+	}
+
+	metadataColumns := map[string]bool{}
+	for _, columns := range [][]*plugin.Column{objectMetadataPrimaryColumns, objectMetadataSecondaryColumns, selectorColumns} {
+		for _, column := range columns {
+			metadataColumns[column.Name] = true
+		}
+	}
+	delete(metadataColumns, "raw")
+
+	for _, column := range d.QueryContext.Columns {
+		if !metadataColumns[column] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+const (
+	matrixKeyContext = "context_name"
+	matrixKeyServer  = "cluster_server"
+)
+
+// allContexts may be given in the contexts connection argument to query every
+// context in the kubeconfig
+const allContexts = "*"
+
+// BuildContextList :: return a list of matrix items, one per kubeconfig context the connection queries
+func BuildContextList(ctx context.Context, connection *plugin.Connection) []map[string]interface{} {
+	logger := plugin.Logger(ctx)
+
+	k8sConfig := GetConfig(connection)
+
+	// connections configured with a host do not use the kubeconfig
+	if k8sConfig.Host != nil && *k8sConfig.Host != "" {
+		return []map[string]interface{}{{matrixKeyServer: *k8sConfig.Host}}
+	}
+
+	loadingRules, err := getLoadingRules(k8sConfig)
+	if err != nil {
+		logger.Warn("BuildContextList", "error", err)
+		return nil
+	}
+
+	// if the kubeconfig cannot be loaded, return no matrix and let
+	// GetNewClientset report the error (or fall back to in-cluster config)
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		logger.Warn("BuildContextList", "error", err)
+		return nil
+	}
+
+	var contextNames []string
+	switch {
+	case helpers.StringSliceContains(k8sConfig.Contexts, allContexts):
+		for name := range rawConfig.Contexts {
+			contextNames = append(contextNames, name)
+		}
+		sort.Strings(contextNames)
+	case len(k8sConfig.Contexts) > 0:
+		contextNames = k8sConfig.Contexts
+	case k8sConfig.ConfigContext != nil && *k8sConfig.ConfigContext != "":
+		contextNames = []string{*k8sConfig.ConfigContext}
+	case rawConfig.CurrentContext != "":
+		contextNames = []string{rawConfig.CurrentContext}
+	default:
+		return nil
+	}
+
+	matrix := make([]map[string]interface{}, len(contextNames))
+	for i, contextName := range contextNames {
+		var server string
+		if kubeContext, ok := rawConfig.Contexts[contextName]; ok {
+			clusterName := kubeContext.Cluster
+			if k8sConfig.Cluster != nil && *k8sConfig.Cluster != "" {
+				clusterName = *k8sConfig.Cluster
+			}
+			if cluster, ok := rawConfig.Clusters[clusterName]; ok {
+				server = cluster.Server
+			}
+		}
+
+		matrix[i] = map[string]interface{}{
+			matrixKeyContext: contextName,
+			matrixKeyServer:  server,
+		}
+	}
+
+	return matrix
+}
+
+// contextMatchesQuals returns false if the query has a context_name = qual
+// which excludes the context of the current matrix item. context_name is a get
+// key column, so get hydrates run once per context and use this to fetch only
+// from the context the query names; without it, the name is found by listing
+// every context.
+func contextMatchesQuals(ctx context.Context, d *plugin.QueryData) bool {
+	contextName, ok := plugin.GetMatrixItem(ctx)[matrixKeyContext].(string)
+	if !ok {
+		return true
+	}
+
+	contextNames, ok := getEqualsQualValues(d, matrixKeyContext)
+	if !ok {
+		return true
+	}
+	return helpers.StringSliceContains(contextNames, contextName)
+}
+
+// skipUnreachableContext wraps a list or get hydrate so that, when the
+// connection queries several contexts, a context the plugin cannot connect to
+// (e.g. an unreachable cluster or expired credentials) is logged and returns
+// no rows, rather than failing the query for every other context
+func skipUnreachableContext(hydrate plugin.HydrateFunc) plugin.HydrateFunc {
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		item, err := hydrate(ctx, d, h)
+
+		var connErr *connectionError
+		if err == nil || !errors.As(err, &connErr) || !queriesManyContexts(GetConfig(d.Connection)) {
+			return item, err
+		}
+
+		contextName, _ := plugin.GetMatrixItem(ctx)[matrixKeyContext].(string)
+		plugin.Logger(ctx).Warn("skipUnreachableContext skipping context", "context", contextName, "error", err)
+		return nil, nil
+	}
+}
+
+// queriesManyContexts returns true if the connection's contexts argument
+// selects more than one context
+func queriesManyContexts(k8sConfig k8sConfig) bool {
+	return len(k8sConfig.Contexts) > 1 || helpers.StringSliceContains(k8sConfig.Contexts, allContexts)
+}
@@ -20,15 +20,20 @@ func Plugin(ctx context.Context) *plugin.Plugin {
 	p := &plugin.Plugin{
 		Name:             pluginName,
 		DefaultTransform: transform.FromGo(),
-		// DefaultGetConfig: &plugin.GetConfig{
-		// 	ShouldIgnoreError: isNotFoundError([]string{"ResourceNotFoundException", "NoSuchEntity"}),
-		// },
+		ConnectionConfigSchema: &plugin.ConnectionConfigSchema{
+			NewInstance: ConfigInstance,
+			Schema:      ConfigSchema,
+		},
+		DefaultGetConfig: &plugin.GetConfig{
+			ShouldIgnoreError: isNotFoundError,
+		},
 		TableMap: map[string]*plugin.Table{
-			"k8s_deployment": tableK8sDeployment(ctx),
-			"k8s_pod":        tableK8sPod(ctx),
-			"k8s_namespace":  tableK8sNamespace(ctx),
-			"k8s_node":       tableK8sNode(ctx),
-			"k8s_replicaset": tableK8sReplicaSet(ctx),
+			"k8s_deployment":    tableK8sDeployment(ctx),
+			"k8s_pod":           tableK8sPod(ctx),
+			"k8s_pod_container": tableK8sPodContainer(ctx),
+			"k8s_namespace":     tableK8sNamespace(ctx),
+			"k8s_node":          tableK8sNode(ctx),
+			"k8s_replicaset":    tableK8sReplicaSet(ctx),
 		},
 	}
 
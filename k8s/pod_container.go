@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
+)
+
+// container types, as given in the container_type column of k8s_pod_container
+const (
+	containerTypeContainer = "container"
+	containerTypeInit      = "init_container"
+	containerTypeEphemeral = "ephemeral_container"
+)
+
+// podContainer is a row of k8s_pod_container: a container of a pod, with its
+// status if the kubelet has reported one
+type podContainer struct {
+	Pod           *corev1.Pod
+	ContainerType string
+	Container     corev1.Container
+	Status        *corev1.ContainerStatus
+	// Image is the container's image reference, split into its parts
+	Image imageReference
+	// RunningDigest is the digest of the image the container is running, if
+	// the container runtime reports one
+	RunningDigest string
+}
+
+// podContainers returns the containers, init containers and ephemeral
+// containers of pod, each joined to its status by name
+func podContainers(pod *corev1.Pod) []podContainer {
+	var containers []podContainer
+
+	add := func(containerType string, container corev1.Container, statuses []corev1.ContainerStatus) {
+		row := podContainer{
+			Pod:           pod,
+			ContainerType: containerType,
+			Container:     container,
+			Image:         parseImageReference(container.Image),
+		}
+		for i := range statuses {
+			if statuses[i].Name == container.Name {
+				row.Status = &statuses[i]
+				row.RunningDigest = imageIDDigest(statuses[i].ImageID)
+				break
+			}
+		}
+		containers = append(containers, row)
+	}
+
+	for _, container := range pod.Spec.InitContainers {
+		add(containerTypeInit, container, pod.Status.InitContainerStatuses)
+	}
+	for _, container := range pod.Spec.Containers {
+		add(containerTypeContainer, container, pod.Status.ContainerStatuses)
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		add(containerTypeEphemeral, corev1.Container(container.EphemeralContainerCommon), pod.Status.EphemeralContainerStatuses)
+	}
+
+	return containers
+}
+
+// containerSecurityContext returns the security setting of the container
+// given as the param: "RunAsUser" or "RunAsNonRoot". As in the kubelet, the
+// container's setting takes precedence over the pod's.
+func containerSecurityContext(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	row, ok := d.HydrateItem.(podContainer)
+	if !ok {
+		return nil, nil
+	}
+
+	var podContext *corev1.PodSecurityContext
+	if row.Pod != nil {
+		podContext = row.Pod.Spec.SecurityContext
+	}
+	containerContext := row.Container.SecurityContext
+
+	switch d.Param {
+	case "RunAsUser":
+		if containerContext != nil && containerContext.RunAsUser != nil {
+			return *containerContext.RunAsUser, nil
+		}
+		if podContext != nil && podContext.RunAsUser != nil {
+			return *podContext.RunAsUser, nil
+		}
+	case "RunAsNonRoot":
+		if containerContext != nil && containerContext.RunAsNonRoot != nil {
+			return *containerContext.RunAsNonRoot, nil
+		}
+		if podContext != nil && podContext.RunAsNonRoot != nil {
+			return *podContext.RunAsNonRoot, nil
+		}
+	}
+	return nil, nil
+}
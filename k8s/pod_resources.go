@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
+)
+
+// podRequestsAndLimits returns the resources requested and limited by pod as
+// the scheduler accounts for them: the sum over its containers, or the largest
+// init container if that is more, plus the pod overhead. A container which
+// does not set a limit for a resource may use all of the node's, so the pod has
+// no limit for that resource at all, and it is left out of limits.
+func podRequestsAndLimits(pod *corev1.Pod) (requests corev1.ResourceList, limits corev1.ResourceList) {
+	requests, limits = corev1.ResourceList{}, corev1.ResourceList{}
+
+	for _, container := range pod.Spec.Containers {
+		addResourceList(requests, container.Resources.Requests)
+		addResourceList(limits, container.Resources.Limits)
+	}
+
+	// init containers run one at a time, before the containers
+	for _, container := range pod.Spec.InitContainers {
+		maxResourceList(requests, container.Resources.Requests)
+		maxResourceList(limits, container.Resources.Limits)
+	}
+
+	// an init container without a limit is unbounded, so it is always the
+	// largest, and like a container without one it leaves the pod unbounded
+	for name := range limits {
+		if !allContainersLimit(pod, name) {
+			delete(limits, name)
+		}
+	}
+
+	if pod.Spec.Overhead != nil {
+		addResourceList(requests, pod.Spec.Overhead)
+		for name, quantity := range pod.Spec.Overhead {
+			if _, ok := limits[name]; ok {
+				addResourceList(limits, corev1.ResourceList{name: quantity})
+			}
+		}
+	}
+
+	return requests, limits
+}
+
+// allContainersLimit returns true if every container and init container of pod
+// sets a limit for the resource name
+func allContainersLimit(pod *corev1.Pod, name corev1.ResourceName) bool {
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for _, container := range containers {
+			if _, ok := container.Resources.Limits[name]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// addResourceList adds the quantities of add to list. The quantities are
+// copied, so the objects they came from are never modified.
+func addResourceList(list corev1.ResourceList, add corev1.ResourceList) {
+	for name, quantity := range add {
+		sum := quantity.DeepCopy()
+		if existing, ok := list[name]; ok {
+			sum.Add(existing)
+		}
+		list[name] = sum
+	}
+}
+
+// maxResourceList sets each quantity of list to the larger of it and the
+// quantity in other
+func maxResourceList(list corev1.ResourceList, other corev1.ResourceList) {
+	for name, quantity := range other {
+		if existing, ok := list[name]; !ok || quantity.Cmp(existing) > 0 {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+// podResourceRequest returns the pod's total request for the resource given
+// as the param, or nil if it requests none
+func podResourceRequest(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	pod, ok := d.HydrateItem.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	requests, _ := podRequestsAndLimits(pod)
+	if quantity, ok := requests[d.Param.(corev1.ResourceName)]; ok {
+		return quantity, nil
+	}
+	return nil, nil
+}
+
+// podResourceLimit returns the pod's total limit for the resource given as
+// the param, or nil if any of its containers does not set one
+func podResourceLimit(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	pod, ok := d.HydrateItem.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	_, limits := podRequestsAndLimits(pod)
+	if quantity, ok := limits[d.Param.(corev1.ResourceName)]; ok {
+		return quantity, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
+)
+
+// podReady returns true if the pod's Ready condition is true, i.e. all of its
+// containers are ready and its readiness gates are met
+func podReady(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	pod, ok := d.HydrateItem.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+// podRestartCountTotal returns the number of times the pod's containers,
+// including its init containers, have been restarted
+func podRestartCountTotal(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	pod, ok := d.HydrateItem.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	var total int32
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			total += status.RestartCount
+		}
+	}
+	return total, nil
+}
+
+// podAge returns the number of whole seconds since the pod was created, as
+// shown in the AGE column of kubectl get pods
+func podAge(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	pod, ok := d.HydrateItem.(*corev1.Pod)
+	if !ok || pod.CreationTimestamp.IsZero() {
+		return nil, nil
+	}
+	return int64(time.Since(pod.CreationTimestamp.Time) / time.Second), nil
+}
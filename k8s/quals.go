@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+// getEqualsQualValues returns the values a string column is restricted to by
+// "=" quals, including "in (...)" lists. ok is false if the query does not
+// restrict the column. If several quals apply, only values satisfying all of
+// them are returned.
+func getEqualsQualValues(d *plugin.QueryData, column string) (values []string, ok bool) {
+	quals, found := d.QueryContext.Quals[column]
+	if !found {
+		return nil, false
+	}
+
+	for _, qual := range quals.Quals {
+		if qual.GetStringValue() != "=" || qual.Value == nil {
+			continue
+		}
+
+		qualValues := qualStringValues(qual.Value)
+		if !ok {
+			values, ok = qualValues, true
+			continue
+		}
+
+		var both []string
+		for _, value := range values {
+			if helpers.StringSliceContains(qualValues, value) {
+				both = append(both, value)
+			}
+		}
+		values = both
+	}
+
+	return values, ok
+}
+
+func qualStringValues(value *proto.QualValue) []string {
+	if list := value.GetListValue(); list != nil {
+		var values []string
+		for _, v := range list.Values {
+			values = append(values, v.GetStringValue())
+		}
+		return values
+	}
+	return []string{value.GetStringValue()}
+}
@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+)
+
+// listSelectors holds the label and field selectors given in the query through
+// the label_selector and field_selector columns
+type listSelectors struct {
+	LabelSelector string
+	FieldSelector string
+}
+
+// getListOptions builds the list options for a list call from the query quals:
+// the label_selector and field_selector columns, labels @> '{...}' conditions,
+// which are translated into a label selector, and "=" conditions on any of the
+// table's selectableFields (column name to field path), which are translated
+// into a field selector
+func getListOptions(d *plugin.QueryData, selectableFields map[string]string) metav1.ListOptions {
+	selectors := getSelectorQuals(d)
+
+	labelSelectors := labelSelectorsFromLabelsQuals(d)
+	if selectors.LabelSelector != "" {
+		labelSelectors = append(labelSelectors, selectors.LabelSelector)
+	}
+
+	fieldSelectors := fieldSelectorsFromQuals(d, selectableFields)
+	if selectors.FieldSelector != "" {
+		fieldSelectors = append(fieldSelectors, selectors.FieldSelector)
+	}
+
+	return metav1.ListOptions{
+		LabelSelector: strings.Join(labelSelectors, ","),
+		FieldSelector: strings.Join(fieldSelectors, ","),
+	}
+}
+
+// fieldSelectorsFromQuals translates "=" quals on the given columns into field
+// selector requirements, for tables whose columns map onto fields the API
+// server can select on. columnFields maps column names to field paths.
+func fieldSelectorsFromQuals(d *plugin.QueryData, columnFields map[string]string) []string {
+	var requirements []string
+	for column, field := range columnFields {
+		if values, ok := getEqualsQualValues(d, column); ok && len(values) == 1 {
+			requirements = append(requirements, fields.OneTermEqualSelector(field, values[0]).String())
+		}
+	}
+	sort.Strings(requirements)
+	return requirements
+}
+
+// getSelectorQuals returns the selectors given in the query. A selector is
+// only used if its column has a single "=" qual.
+func getSelectorQuals(d *plugin.QueryData) listSelectors {
+	var selectors listSelectors
+	if values, ok := getEqualsQualValues(d, "label_selector"); ok && len(values) == 1 {
+		selectors.LabelSelector = values[0]
+	}
+	if values, ok := getEqualsQualValues(d, "field_selector"); ok && len(values) == 1 {
+		selectors.FieldSelector = values[0]
+	}
+	return selectors
+}
+
+// labelSelectorsFromLabelsQuals translates labels @> '{"app": "x"}' quals into
+// label selector requirements. Postgres still applies the condition to the
+// rows we return, so quals we cannot translate are simply not pushed down.
+func labelSelectorsFromLabelsQuals(d *plugin.QueryData) []string {
+	quals, ok := d.QueryContext.Quals["labels"]
+	if !ok {
+		return nil
+	}
+
+	var requirements []string
+	for _, qual := range quals.Quals {
+		if qual.GetStringValue() != "@>" || qual.Value == nil {
+			continue
+		}
+		var labelMap map[string]string
+		if err := json.Unmarshal([]byte(qual.Value.GetJsonbValue()), &labelMap); err != nil {
+			continue
+		}
+		for key, value := range labelMap {
+			requirement := fmt.Sprintf("%s=%s", key, value)
+			if _, err := labels.Parse(requirement); err != nil {
+				continue
+			}
+			requirements = append(requirements, requirement)
+		}
+	}
+	// keep the selector stable for logging and caching
+	sort.Strings(requirements)
+	return requirements
+}
+
+// getListSelectors populates the label_selector and field_selector columns.
+// These are virtual columns: each row echoes the selector from the query so
+// that Postgres keeps the rows the API server returned for it.
+func getListSelectors(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	selectors := getSelectorQuals(d)
+
+	if selectors.LabelSelector != "" {
+		// check the row matches, as get calls do not apply the selector
+		selector, err := labels.Parse(selectors.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		object, err := meta.Accessor(h.Item)
+		if err != nil {
+			return nil, err
+		}
+		if !selector.Matches(labels.Set(object.GetLabels())) {
+			selectors.LabelSelector = ""
+		}
+	}
+
+	if selectors.FieldSelector != "" && d.FetchType != "list" {
+		return nil, fmt.Errorf("field_selector cannot be combined with a lookup by name, use name and namespace conditions alone")
+	}
+
+	return selectors, nil
+}
@@ -3,9 +3,13 @@ package k8s
 import (
 	"context"
 
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
 )
 
 func tableK8sDeployment(ctx context.Context) *plugin.Table {
@@ -13,44 +17,179 @@ func tableK8sDeployment(ctx context.Context) *plugin.Table {
 		Name:        "k8s_deployment",
 		Description: "Kubernetes Deployment enables declarative updates for Pods and ReplicaSets.",
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.AllColumns([]string{"name", "namespace"}),
-			Hydrate:    getK8sDeployment,
+			KeyColumns: plugin.AllColumns([]string{"name", "namespace", "context_name"}),
+			Hydrate:    skipUnreachableContext(getK8sDeployment),
 		},
+		GetMatrixItem: BuildContextList,
 		List: &plugin.ListConfig{
-			Hydrate: listK8sDeployments,
+			Hydrate: skipUnreachableContext(listK8sDeployments),
 		},
-		Columns: k8sCommonColumns([]*plugin.Column{}),
+		Columns: k8sCommonColumns("deployment", []*plugin.Column{
+			// deployment columns
+			{
+				Name:        "replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "Number of desired pods. Defaults to 1.",
+				Transform:   transform.FromField("Spec.Replicas"),
+			},
+			{
+				Name:        "ready_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "Total number of ready pods targeted by this deployment.",
+				Transform:   transform.FromField("Status.ReadyReplicas"),
+			},
+			{
+				Name:        "available_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "Total number of available pods (ready for at least minReadySeconds) targeted by this deployment.",
+				Transform:   transform.FromField("Status.AvailableReplicas"),
+			},
+			{
+				Name:        "updated_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "Total number of non-terminated pods targeted by this deployment that have the desired template spec.",
+				Transform:   transform.FromField("Status.UpdatedReplicas"),
+			},
+			{
+				Name: "unavailable_replicas",
+				Type: proto.ColumnType_INT,
+				Description: "Total number of unavailable pods targeted by this deployment. " +
+					"This is the total number of pods that are still required for the deployment to have 100% available capacity. " +
+					"They may either be pods that are running but not yet available or pods that still have not been created.",
+				Transform: transform.FromField("Status.UnavailableReplicas"),
+			},
+			{
+				Name:        "status_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "Total number of non-terminated pods targeted by this deployment (their labels match the selector).",
+				Transform:   transform.FromField("Status.Replicas"),
+			},
+			{
+				Name:        "observed_generation",
+				Type:        proto.ColumnType_INT,
+				Description: "The generation observed by the deployment controller.",
+				Transform:   transform.FromField("Status.ObservedGeneration"),
+			},
+			{
+				Name: "collision_count",
+				Type: proto.ColumnType_INT,
+				Description: "Count of hash collisions for the Deployment. " +
+					"The Deployment controller uses this field as a collision avoidance mechanism when it needs to create the name for the newest ReplicaSet.",
+				Transform: transform.FromField("Status.CollisionCount"),
+			},
+			{
+				Name:        "strategy_type",
+				Type:        proto.ColumnType_STRING,
+				Description: "Type of deployment. Can be \"Recreate\" or \"RollingUpdate\". Default is RollingUpdate.",
+				Transform:   transform.FromField("Spec.Strategy.Type"),
+			},
+			{
+				Name: "max_surge",
+				Type: proto.ColumnType_STRING,
+				Description: "The maximum number of pods that can be scheduled above the desired number of pods during a rolling update, " +
+					"as a number or a percentage of desired pods, e.g. 25%.",
+				Transform: transform.FromField("Spec.Strategy.RollingUpdate.MaxSurge").Transform(intOrStringToString),
+			},
+			{
+				Name: "max_unavailable",
+				Type: proto.ColumnType_STRING,
+				Description: "The maximum number of pods that can be unavailable during a rolling update, " +
+					"as a number or a percentage of desired pods, e.g. 25%.",
+				Transform: transform.FromField("Spec.Strategy.RollingUpdate.MaxUnavailable").Transform(intOrStringToString),
+			},
+			{
+				Name: "selector",
+				Type: proto.ColumnType_JSON,
+				Description: "Label selector for pods. " +
+					"Existing ReplicaSets whose pods are selected by this will be the ones affected by this deployment. " +
+					"It must match the pod template's labels.",
+				Transform: transform.FromField("Spec.Selector"),
+			},
+			{
+				Name: "min_ready_seconds",
+				Type: proto.ColumnType_INT,
+				Description: "Minimum number of seconds for which a newly created pod should be ready without any of its container crashing, for it to be considered available. " +
+					"Defaults to 0 (pod will be considered available as soon as it is ready).",
+				Transform: transform.FromField("Spec.MinReadySeconds"),
+			},
+			{
+				Name: "progress_deadline_seconds",
+				Type: proto.ColumnType_INT,
+				Description: "The maximum time in seconds for a deployment to make progress before it is considered to be failed. " +
+					"The deployment controller will continue to process failed deployments and a condition with a ProgressDeadlineExceeded reason will be surfaced in the deployment status. " +
+					"Defaults to 600s.",
+				Transform: transform.FromField("Spec.ProgressDeadlineSeconds"),
+			},
+			{
+				Name:        "paused",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Indicates that the deployment is paused.",
+				Transform:   transform.FromField("Spec.Paused"),
+			},
+			{
+				Name:        "revision_history_limit",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of old ReplicaSets to retain to allow rollback. Defaults to 10.",
+				Transform:   transform.FromField("Spec.RevisionHistoryLimit"),
+			},
+			{
+				Name:        "conditions",
+				Type:        proto.ColumnType_JSON,
+				Description: "Represents the latest available observations of a deployment's current state.",
+				Transform:   transform.FromField("Status.Conditions"),
+			},
+			{
+				Name:        "template",
+				Type:        proto.ColumnType_JSON,
+				Description: "Template describes the pods that will be created.",
+				Transform:   transform.FromField("Spec.Template"),
+			},
+		}),
 	}
 }
 
+// deployment columns the API server can filter on, mapped to their field selector paths
+var deploymentSelectableFields = map[string]string{
+	"name": "metadata.name",
+}
+
 //// HYDRATE FUNCTIONS
 
+var deploymentResource = appsv1.SchemeGroupVersion.WithResource("deployments")
+
 func listK8sDeployments(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listK8sDeployments")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
-	if err != nil {
+	opts := getListOptions(d, deploymentSelectableFields)
+	if served, err := listFromInformerCache(ctx, d, deploymentResource, true, opts); served {
+		return nil, err
+	}
+	if served, err := listMetadataOnly(ctx, d, deploymentResource, true, opts); served {
 		return nil, err
 	}
 
-	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, item := range deployments.Items {
-		d.StreamListItem(ctx, item)
-	}
+	err = streamNamespacedList(ctx, d, opts, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+	})
 
-	return nil, nil
+	return nil, err
 }
 
 func getK8sDeployment(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("getK8sDeployment")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
+	if !contextMatchesQuals(ctx, d) {
+		return nil, nil
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +197,20 @@ func getK8sDeployment(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 	name := d.KeyColumnQuals["name"].GetStringValue()
 	namespace := d.KeyColumnQuals["namespace"].GetStringValue()
 
-	deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-	if err != nil && !isNotFoundError(err) {
+	if !namespaceAllowed(d, namespace) {
+		return nil, nil
+	}
+
+	if item, served, err := getFromInformerCache(ctx, d, deploymentResource, namespace, name); served {
+		return item, err
+	}
+
+	var deployment *appsv1.Deployment
+	err = retryTransientErrors(ctx, func() (err error) {
+		deployment, err = clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -3,9 +3,13 @@ package k8s
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
 )
 
 func tableK8sNamespace(ctx context.Context) *plugin.Table {
@@ -13,52 +17,98 @@ func tableK8sNamespace(ctx context.Context) *plugin.Table {
 		Name:        "k8s_namespace",
 		Description: "Kubernetes Namespace provides a scope for Names.",
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.SingleColumn("name"),
-			Hydrate:    getK8sNamespace,
+			KeyColumns: plugin.AllColumns([]string{"name", "context_name"}),
+			Hydrate:    skipUnreachableContext(getK8sNamespace),
 		},
+		GetMatrixItem: BuildContextList,
 		List: &plugin.ListConfig{
-			Hydrate: listK8sNamespaces,
+			Hydrate: skipUnreachableContext(listK8sNamespaces),
 		},
-		Columns: k8sCommonColumns([]*plugin.Column{}),
+		Columns: k8sCommonColumns("namespace", []*plugin.Column{
+			// namespace columns
+			{
+				Name:        "phase",
+				Type:        proto.ColumnType_STRING,
+				Description: "Phase is the current lifecycle phase of the namespace, Active or Terminating.",
+				Transform:   transform.FromField("Status.Phase"),
+			},
+			{
+				Name: "spec_finalizers",
+				Type: proto.ColumnType_JSON,
+				Description: "Finalizers is an opaque list of values that must be empty to permanently remove the namespace from storage, " +
+					"e.g. kubernetes. " +
+					"Unlike the object finalizers column, these are cleared by the namespace controller as it deletes the namespace's content.",
+				Transform: transform.FromField("Spec.Finalizers"),
+			},
+			{
+				Name:        "conditions",
+				Type:        proto.ColumnType_JSON,
+				Description: "Represents the latest available observations of a namespace's current state, such as why it cannot finish terminating.",
+				Transform:   transform.FromField("Status.Conditions"),
+			},
+		}),
 	}
 }
 
+// namespace columns the API server can filter on, mapped to their field selector paths
+var namespaceSelectableFields = map[string]string{
+	"name":  "metadata.name",
+	"phase": "status.phase",
+}
+
 //// HYDRATE FUNCTIONS
 
+var namespaceResource = corev1.SchemeGroupVersion.WithResource("namespaces")
+
 func listK8sNamespaces(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listK8sNamespaces")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
-	if err != nil {
+	opts := getListOptions(d, namespaceSelectableFields)
+	if served, err := listFromInformerCache(ctx, d, namespaceResource, false, opts); served {
+		return nil, err
+	}
+	if served, err := listMetadataOnly(ctx, d, namespaceResource, false, opts); served {
 		return nil, err
 	}
 
-	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range namespaces.Items {
-		d.StreamListItem(ctx, pod)
-	}
+	err = streamList(ctx, d, opts, func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, opts)
+	})
 
-	return nil, nil
+	return nil, err
 }
 
 func getK8sNamespace(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("getK8sNamespace")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
+	if !contextMatchesQuals(ctx, d) {
+		return nil, nil
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
 	name := d.KeyColumnQuals["name"].GetStringValue()
 
-	namespace, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
-	if err != nil && !isNotFoundError(err) {
+	if item, served, err := getFromInformerCache(ctx, d, namespaceResource, "", name); served {
+		return item, err
+	}
+
+	var namespace *corev1.Namespace
+	err = retryTransientErrors(ctx, func() (err error) {
+		namespace, err = clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
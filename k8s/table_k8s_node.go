@@ -3,9 +3,13 @@ package k8s
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
 )
 
 func tableK8sNode(ctx context.Context) *plugin.Table {
@@ -13,52 +17,253 @@ func tableK8sNode(ctx context.Context) *plugin.Table {
 		Name:        "k8s_node",
 		Description: "Kubernetes Node is a worker node in Kubernetes.",
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.SingleColumn("name"),
-			Hydrate:    getK8sNode,
+			KeyColumns: plugin.AllColumns([]string{"name", "context_name"}),
+			Hydrate:    skipUnreachableContext(getK8sNode),
 		},
+		GetMatrixItem: BuildContextList,
 		List: &plugin.ListConfig{
-			Hydrate: listK8sNodes,
+			Hydrate: skipUnreachableContext(listK8sNodes),
 		},
-		Columns: k8sCommonColumns([]*plugin.Column{}),
+		Columns: k8sCommonColumns("node", []*plugin.Column{
+			// node columns
+			{
+				Name:        "pod_cidr",
+				Type:        proto.ColumnType_STRING,
+				Description: "PodCIDR represents the pod IP range assigned to the node.",
+				Transform:   transform.FromField("Spec.PodCIDR"),
+			},
+			{
+				Name: "pod_cidrs",
+				Type: proto.ColumnType_JSON,
+				Description: "PodCIDRs represents the IP ranges assigned to the node for usage by Pods on that node. " +
+					"If this field is specified, the 0th entry must match the podCIDR field. " +
+					"It may contain at most 1 value for each of IPv4 and IPv6.",
+				Transform: transform.FromField("Spec.PodCIDRs"),
+			},
+			{
+				Name:        "provider_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "ID of the node assigned by the cloud provider in the format: <ProviderName>://<ProviderSpecificNodeID>.",
+				Transform:   transform.FromField("Spec.ProviderID"),
+			},
+			{
+				Name:        "unschedulable",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Unschedulable controls node schedulability of new pods. By default, node is schedulable.",
+				Transform:   transform.FromField("Spec.Unschedulable"),
+			},
+			{
+				Name:        "taints",
+				Type:        proto.ColumnType_JSON,
+				Description: "If specified, the node's taints.",
+				Transform:   transform.FromField("Spec.Taints"),
+			},
+			{
+				Name:        "capacity",
+				Type:        proto.ColumnType_JSON,
+				Description: "Capacity represents the total resources of a node.",
+				Transform:   transform.FromField("Status.Capacity"),
+			},
+			{
+				Name:        "allocatable",
+				Type:        proto.ColumnType_JSON,
+				Description: "Allocatable represents the resources of a node that are available for scheduling. Defaults to Capacity.",
+				Transform:   transform.FromField("Status.Allocatable"),
+			},
+			{
+				Name:        "cpu_capacity_millicores",
+				Type:        proto.ColumnType_INT,
+				Description: "Total CPU of the node, in millicores.",
+				Transform:   transform.FromField("Status.Capacity").TransformP(resourceListQuantity, corev1.ResourceCPU).TransformP(resourceQuantityToNumber, "millis"),
+			},
+			{
+				Name:        "memory_capacity_bytes",
+				Type:        proto.ColumnType_INT,
+				Description: "Total memory of the node, in bytes.",
+				Transform:   transform.FromField("Status.Capacity").TransformP(resourceListQuantity, corev1.ResourceMemory).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name:        "pods_capacity",
+				Type:        proto.ColumnType_INT,
+				Description: "Maximum number of pods the node can run.",
+				Transform:   transform.FromField("Status.Capacity").TransformP(resourceListQuantity, corev1.ResourcePods).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name:        "cpu_allocatable_millicores",
+				Type:        proto.ColumnType_INT,
+				Description: "CPU of the node available for scheduling pods, in millicores.",
+				Transform:   transform.FromField("Status.Allocatable").TransformP(resourceListQuantity, corev1.ResourceCPU).TransformP(resourceQuantityToNumber, "millis"),
+			},
+			{
+				Name:        "memory_allocatable_bytes",
+				Type:        proto.ColumnType_INT,
+				Description: "Memory of the node available for scheduling pods, in bytes.",
+				Transform:   transform.FromField("Status.Allocatable").TransformP(resourceListQuantity, corev1.ResourceMemory).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name:        "pods_allocatable",
+				Type:        proto.ColumnType_INT,
+				Description: "Number of pods which may be scheduled to the node.",
+				Transform:   transform.FromField("Status.Allocatable").TransformP(resourceListQuantity, corev1.ResourcePods).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name:        "conditions",
+				Type:        proto.ColumnType_JSON,
+				Description: "Conditions is an array of current observed node conditions, such as Ready, MemoryPressure and DiskPressure.",
+				Transform:   transform.FromField("Status.Conditions"),
+			},
+			{
+				Name:        "addresses",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of addresses reachable to the node, such as its InternalIP, ExternalIP and Hostname. Queried from cloud provider, if available.",
+				Transform:   transform.FromField("Status.Addresses"),
+			},
+			{
+				Name:        "daemon_endpoints",
+				Type:        proto.ColumnType_JSON,
+				Description: "Endpoints of daemons running on the node, such as the kubelet.",
+				Transform:   transform.FromField("Status.DaemonEndpoints"),
+			},
+			{
+				Name:        "machine_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "MachineID reported by the node. For unique machine identification in the cluster this field is preferred.",
+				Transform:   transform.FromField("Status.NodeInfo.MachineID"),
+			},
+			{
+				Name:        "system_uuid",
+				Type:        proto.ColumnType_STRING,
+				Description: "SystemUUID reported by the node. For unique machine identification MachineID is preferred.",
+				Transform:   transform.FromField("Status.NodeInfo.SystemUUID"),
+			},
+			{
+				Name:        "boot_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "Boot ID reported by the node.",
+				Transform:   transform.FromField("Status.NodeInfo.BootID"),
+			},
+			{
+				Name:        "kernel_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "Kernel Version reported by the node from 'uname -r' (e.g. 3.16.0-0.bpo.4-amd64).",
+				Transform:   transform.FromField("Status.NodeInfo.KernelVersion"),
+			},
+			{
+				Name:        "os_image",
+				Type:        proto.ColumnType_STRING,
+				Description: "OS Image reported by the node from /etc/os-release (e.g. Debian GNU/Linux 7 (wheezy)).",
+				Transform:   transform.FromField("Status.NodeInfo.OSImage"),
+			},
+			{
+				Name:        "container_runtime_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "ContainerRuntime Version reported by the node through runtime remote API (e.g. docker://1.5.0).",
+				Transform:   transform.FromField("Status.NodeInfo.ContainerRuntimeVersion"),
+			},
+			{
+				Name:        "kubelet_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "Kubelet Version reported by the node.",
+				Transform:   transform.FromField("Status.NodeInfo.KubeletVersion"),
+			},
+			{
+				Name:        "kube_proxy_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "KubeProxy Version reported by the node.",
+				Transform:   transform.FromField("Status.NodeInfo.KubeProxyVersion"),
+			},
+			{
+				Name:        "operating_system",
+				Type:        proto.ColumnType_STRING,
+				Description: "The Operating System reported by the node.",
+				Transform:   transform.FromField("Status.NodeInfo.OperatingSystem"),
+			},
+			{
+				Name:        "architecture",
+				Type:        proto.ColumnType_STRING,
+				Description: "The Architecture reported by the node.",
+				Transform:   transform.FromField("Status.NodeInfo.Architecture"),
+			},
+			{
+				Name:        "images",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of container images on this node.",
+				Transform:   transform.FromField("Status.Images"),
+			},
+			{
+				Name:        "volumes_in_use",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of attachable volumes in use (mounted) by the node.",
+				Transform:   transform.FromField("Status.VolumesInUse"),
+			},
+			{
+				Name:        "volumes_attached",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of volumes that are attached to the node.",
+				Transform:   transform.FromField("Status.VolumesAttached"),
+			},
+		}),
 	}
 }
 
+// node columns the API server can filter on, mapped to their field selector paths
+var nodeSelectableFields = map[string]string{
+	"name": "metadata.name",
+}
+
 //// HYDRATE FUNCTIONS
 
+var nodeResource = corev1.SchemeGroupVersion.WithResource("nodes")
+
 func listK8sNodes(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listK8sNodes")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
-	if err != nil {
+	opts := getListOptions(d, nodeSelectableFields)
+	if served, err := listFromInformerCache(ctx, d, nodeResource, false, opts); served {
+		return nil, err
+	}
+	if served, err := listMetadataOnly(ctx, d, nodeResource, false, opts); served {
 		return nil, err
 	}
 
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range nodes.Items {
-		d.StreamListItem(ctx, pod)
-	}
+	err = streamList(ctx, d, opts, func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.CoreV1().Nodes().List(ctx, opts)
+	})
 
-	return nil, nil
+	return nil, err
 }
 
 func getK8sNode(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("getK8sNode")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
+	if !contextMatchesQuals(ctx, d) {
+		return nil, nil
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
 	name := d.KeyColumnQuals["name"].GetStringValue()
 
-	node, err := clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
-	if err != nil && !isNotFoundError(err) {
+	if item, served, err := getFromInformerCache(ctx, d, nodeResource, "", name); served {
+		return item, err
+	}
+
+	var node *corev1.Node
+	err = retryTransientErrors(ctx, func() (err error) {
+		node, err = clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
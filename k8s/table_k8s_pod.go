@@ -3,7 +3,9 @@ package k8s
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
@@ -15,13 +17,14 @@ func tableK8sPod(ctx context.Context) *plugin.Table {
 		Name:        "k8s_pod",
 		Description: "Kubernetes Pod is a collection of containers that can run on a host. This resource is created by clients and scheduled onto hosts.",
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.AllColumns([]string{"name", "namespace"}),
-			Hydrate:    getK8sPod,
+			KeyColumns: plugin.AllColumns([]string{"name", "namespace", "context_name"}),
+			Hydrate:    skipUnreachableContext(getK8sPod),
 		},
+		GetMatrixItem: BuildContextList,
 		List: &plugin.ListConfig{
-			Hydrate: listK8sPods,
+			Hydrate: skipUnreachableContext(listK8sPods),
 		},
-		Columns: k8sCommonColumns([]*plugin.Column{
+		Columns: k8sCommonColumns("pod", []*plugin.Column{
 			// pod columns
 			{
 				Name:        "volumes",
@@ -279,38 +282,187 @@ func tableK8sPod(ctx context.Context) *plugin.Table {
 					"If a pod does not have FQDN, this has no effect.",
 				Transform: transform.FromField("Spec.SetHostnameAsFQDN"),
 			},
+			{
+				Name: "phase",
+				Type: proto.ColumnType_STRING,
+				Description: "The phase of a Pod is a simple, high-level summary of where the Pod is in its lifecycle. " +
+					"One of Pending, Running, Succeeded, Failed or Unknown.",
+				Transform: transform.FromField("Status.Phase"),
+			},
+			{
+				Name:        "reason",
+				Type:        proto.ColumnType_STRING,
+				Description: "A brief CamelCase message indicating details about why the pod is in this state. e.g. 'Evicted'.",
+				Transform:   transform.FromField("Status.Reason"),
+			},
+			{
+				Name:        "message",
+				Type:        proto.ColumnType_STRING,
+				Description: "A human readable message indicating details about why the pod is in this condition.",
+				Transform:   transform.FromField("Status.Message"),
+			},
+			{
+				Name:        "ready",
+				Type:        proto.ColumnType_BOOL,
+				Description: "True if the pod's Ready condition is true: all of its containers are ready and its readiness gates are met.",
+				Transform:   transform.From(podReady),
+			},
+			{
+				Name:        "restart_count_total",
+				Type:        proto.ColumnType_INT,
+				Description: "The total number of times the pod's containers, including init containers, have been restarted.",
+				Transform:   transform.From(podRestartCountTotal),
+			},
+			{
+				Name:        "age",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of seconds since the pod was created, as shown in the AGE column of kubectl get pods.",
+				Transform:   transform.From(podAge),
+			},
+			{
+				Name: "pod_ip",
+				Type: proto.ColumnType_IPADDR,
+				Description: "IP address allocated to the pod. Routable at least within the cluster. " +
+					"Empty if not yet allocated.",
+				Transform: transform.FromField("Status.PodIP").NullIfZero(),
+			},
+			{
+				Name: "pod_ips",
+				Type: proto.ColumnType_JSON,
+				Description: "PodIPs holds the IP addresses allocated to the pod. If this field is specified, the 0th entry must " +
+					"match the podIP field. Pods may be allocated at most 1 value for each of IPv4 and IPv6.",
+				Transform: transform.FromField("Status.PodIPs"),
+			},
+			{
+				Name:        "host_ip",
+				Type:        proto.ColumnType_IPADDR,
+				Description: "IP address of the host to which the pod is assigned. Empty if not yet scheduled.",
+				Transform:   transform.FromField("Status.HostIP").NullIfZero(),
+			},
+			{
+				Name: "start_time",
+				Type: proto.ColumnType_TIMESTAMP,
+				Description: "RFC 3339 date and time at which the object was acknowledged by the Kubelet. " +
+					"This is before the Kubelet pulled the container image(s) for the pod.",
+				Transform: transform.FromField("Status.StartTime").Transform(v1TimeToRFC3339),
+			},
+			{
+				Name: "qos_class",
+				Type: proto.ColumnType_STRING,
+				Description: "The Quality of Service (QOS) classification assigned to the pod based on resource requirements. " +
+					"One of Guaranteed, Burstable or BestEffort.",
+				Transform: transform.FromField("Status.QOSClass"),
+			},
+			{
+				Name: "nominated_node_name",
+				Type: proto.ColumnType_STRING,
+				Description: "nominatedNodeName is set only when this pod preempts other pods on the node, but it cannot be " +
+					"scheduled right away as preemption victims receive their graceful termination periods.",
+				Transform: transform.FromField("Status.NominatedNodeName"),
+			},
+			{
+				Name:        "conditions",
+				Type:        proto.ColumnType_JSON,
+				Description: "Current service state of pod, such as PodScheduled, Initialized, ContainersReady and Ready.",
+				Transform:   transform.FromField("Status.Conditions"),
+			},
+			{
+				Name: "container_statuses",
+				Type: proto.ColumnType_JSON,
+				Description: "The list has one entry per container in the manifest. Each entry is currently the output of " +
+					"`docker inspect`.",
+				Transform: transform.FromField("Status.ContainerStatuses"),
+			},
+			{
+				Name: "init_container_statuses",
+				Type: proto.ColumnType_JSON,
+				Description: "The list has one entry per init container in the manifest. The most recent successful " +
+					"init container will have ready = true, the most recently started container will have startTime set.",
+				Transform: transform.FromField("Status.InitContainerStatuses"),
+			},
+			{
+				Name:        "ephemeral_container_statuses",
+				Type:        proto.ColumnType_JSON,
+				Description: "Status for any ephemeral containers that have run in this pod.",
+				Transform:   transform.FromField("Status.EphemeralContainerStatuses"),
+			},
+			{
+				Name: "cpu_request_millicores",
+				Type: proto.ColumnType_INT,
+				Description: "Total CPU requested by the pod, in millicores: the sum of its containers' requests, " +
+					"or the largest init container request if that is more, plus the pod overhead.",
+				Transform: transform.FromP(podResourceRequest, corev1.ResourceCPU).TransformP(resourceQuantityToNumber, "millis"),
+			},
+			{
+				Name: "cpu_limit_millicores",
+				Type: proto.ColumnType_INT,
+				Description: "Total CPU limit of the pod, in millicores, summed as for cpu_request_millicores. " +
+					"Null if any container or init container does not set a CPU limit, as the pod is then unbounded.",
+				Transform: transform.FromP(podResourceLimit, corev1.ResourceCPU).TransformP(resourceQuantityToNumber, "millis"),
+			},
+			{
+				Name: "memory_request_bytes",
+				Type: proto.ColumnType_INT,
+				Description: "Total memory requested by the pod, in bytes: the sum of its containers' requests, " +
+					"or the largest init container request if that is more, plus the pod overhead.",
+				Transform: transform.FromP(podResourceRequest, corev1.ResourceMemory).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name: "memory_limit_bytes",
+				Type: proto.ColumnType_INT,
+				Description: "Total memory limit of the pod, in bytes, summed as for memory_request_bytes. " +
+					"Null if any container or init container does not set a memory limit, as the pod is then unbounded.",
+				Transform: transform.FromP(podResourceLimit, corev1.ResourceMemory).Transform(resourceQuantityToNumber),
+			},
 		}),
 	}
 }
 
+// pod columns the API server can filter on, mapped to their field selector paths
+var podSelectableFields = map[string]string{
+	"name":                 "metadata.name",
+	"node_name":            "spec.nodeName",
+	"phase":                "status.phase",
+	"service_account_name": "spec.serviceAccountName",
+}
+
 //// HYDRATE FUNCTIONS
 
+var podResource = corev1.SchemeGroupVersion.WithResource("pods")
+
 func listK8sPods(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listK8sPods")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
-	if err != nil {
+	opts := getListOptions(d, podSelectableFields)
+	if served, err := listFromInformerCache(ctx, d, podResource, true, opts); served {
+		return nil, err
+	}
+	if served, err := listMetadataOnly(ctx, d, podResource, true, opts); served {
 		return nil, err
 	}
 
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range pods.Items {
-		d.StreamListItem(ctx, pod)
-	}
+	err = streamNamespacedList(ctx, d, opts, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.CoreV1().Pods(namespace).List(ctx, opts)
+	})
 
-	return nil, nil
+	return nil, err
 }
 
 func getK8sPod(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("getK8sPod")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
+	if !contextMatchesQuals(ctx, d) {
+		return nil, nil
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
@@ -318,8 +470,20 @@ func getK8sPod(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData)
 	name := d.KeyColumnQuals["name"].GetStringValue()
 	namespace := d.KeyColumnQuals["namespace"].GetStringValue()
 
-	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
-	if err != nil && !isNotFoundError(err) {
+	if !namespaceAllowed(d, namespace) {
+		return nil, nil
+	}
+
+	if item, served, err := getFromInformerCache(ctx, d, podResource, namespace, name); served {
+		return item, err
+	}
+
+	var pod *corev1.Pod
+	err = retryTransientErrors(ctx, func() (err error) {
+		pod, err = clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
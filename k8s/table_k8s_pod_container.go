@@ -0,0 +1,315 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
+)
+
+func tableK8sPodContainer(ctx context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:          "k8s_pod_container",
+		Description:   "Containers of Kubernetes Pods, one row per container, init container or ephemeral container, with its status.",
+		GetMatrixItem: BuildContextList,
+		List: &plugin.ListConfig{
+			Hydrate: skipUnreachableContext(listK8sPodContainers),
+		},
+		Columns: []*plugin.Column{
+			// pod columns
+			{Name: "pod_name", Type: proto.ColumnType_STRING, Description: "Name of the pod the container belongs to.", Transform: transform.FromField("Pod.Name")},
+			{Name: "namespace", Type: proto.ColumnType_STRING, Description: "Namespace of the pod the container belongs to.", Transform: transform.FromField("Pod.Namespace")},
+			{Name: "pod_uid", Type: proto.ColumnType_STRING, Description: "UID of the pod the container belongs to.", Transform: transform.FromField("Pod.UID")},
+			{Name: "node_name", Type: proto.ColumnType_STRING, Description: "Name of the node the pod is scheduled onto.", Transform: transform.FromField("Pod.Spec.NodeName")},
+			{Name: "context_name", Type: proto.ColumnType_STRING, Transform: transform.FromMatrixItem(matrixKeyContext), Description: "The name of the kubeconfig context the pod was read from."},
+			{Name: "cluster_server", Type: proto.ColumnType_STRING, Transform: transform.FromMatrixItem(matrixKeyServer), Description: "The API server URL of the cluster the pod was read from."},
+
+			// container spec columns
+			{
+				Name:        "name",
+				Type:        proto.ColumnType_STRING,
+				Description: "Name of the container. Each container in a pod must have a unique name.",
+				Transform:   transform.FromField("Container.Name"),
+			},
+			{
+				Name:        "container_type",
+				Type:        proto.ColumnType_STRING,
+				Description: "The kind of container: container, init_container or ephemeral_container.",
+				Transform:   transform.FromField("ContainerType"),
+			},
+			{
+				Name:        "image",
+				Type:        proto.ColumnType_STRING,
+				Description: "Docker image name.",
+				Transform:   transform.FromField("Container.Image"),
+			},
+			{
+				Name:        "registry",
+				Type:        proto.ColumnType_STRING,
+				Description: "Registry of the container image, e.g. docker.io for images named without a registry.",
+				Transform:   transform.FromField("Image.Registry").NullIfZero(),
+			},
+			{
+				Name:        "repository",
+				Type:        proto.ColumnType_STRING,
+				Description: "Repository of the container image within its registry, e.g. library/nginx for the image nginx.",
+				Transform:   transform.FromField("Image.Repository").NullIfZero(),
+			},
+			{
+				Name:        "tag",
+				Type:        proto.ColumnType_STRING,
+				Description: "Tag of the container image, if the image names one.",
+				Transform:   transform.FromField("Image.Tag").NullIfZero(),
+			},
+			{
+				Name:        "digest",
+				Type:        proto.ColumnType_STRING,
+				Description: "Digest the container image is pinned to, e.g. sha256:..., if the image names one.",
+				Transform:   transform.FromField("Image.Digest").NullIfZero(),
+			},
+			{
+				Name:        "normalized_image",
+				Type:        proto.ColumnType_STRING,
+				Description: "The container image with its registry and repository written out in full, e.g. docker.io/library/nginx:1.19 for nginx:1.19.",
+				Transform:   transform.FromField("Image.Normalized").NullIfZero(),
+			},
+			{
+				Name:        "is_latest_or_untagged",
+				Type:        proto.ColumnType_BOOL,
+				Description: "True if the container image has the tag latest, or has neither a tag nor a digest and so pulls latest.",
+				Transform:   transform.FromField("Image.IsLatestOrUntagged"),
+			},
+			{
+				Name: "running_digest",
+				Type: proto.ColumnType_STRING,
+				Description: "Digest of the image the container is running, from the image_id in its status. " +
+					"Null if the container runtime reports only a local image ID.",
+				Transform: transform.FromField("RunningDigest").NullIfZero(),
+			},
+			{
+				Name:        "image_pull_policy",
+				Type:        proto.ColumnType_STRING,
+				Description: "Image pull policy. One of Always, Never, IfNotPresent.",
+				Transform:   transform.FromField("Container.ImagePullPolicy"),
+			},
+			{
+				Name:        "command",
+				Type:        proto.ColumnType_JSON,
+				Description: "Entrypoint array. Not executed within a shell. The docker image's ENTRYPOINT is used if this is not provided.",
+				Transform:   transform.FromField("Container.Command"),
+			},
+			{
+				Name:        "args",
+				Type:        proto.ColumnType_JSON,
+				Description: "Arguments to the entrypoint. The docker image's CMD is used if this is not provided.",
+				Transform:   transform.FromField("Container.Args"),
+			},
+			{
+				Name:        "working_dir",
+				Type:        proto.ColumnType_STRING,
+				Description: "Container's working directory. If not specified, the container runtime's default will be used.",
+				Transform:   transform.FromField("Container.WorkingDir"),
+			},
+			{
+				Name:        "ports",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of ports to expose from the container.",
+				Transform:   transform.FromField("Container.Ports"),
+			},
+			{
+				Name:        "env",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of environment variables to set in the container.",
+				Transform:   transform.FromField("Container.Env"),
+			},
+			{
+				Name:        "env_from",
+				Type:        proto.ColumnType_JSON,
+				Description: "List of sources to populate environment variables in the container, such as config maps and secrets.",
+				Transform:   transform.FromField("Container.EnvFrom"),
+			},
+			{
+				Name:        "resources",
+				Type:        proto.ColumnType_JSON,
+				Description: "Compute resources required by this container.",
+				Transform:   transform.FromField("Container.Resources"),
+			},
+			{
+				Name:        "cpu_request_millicores",
+				Type:        proto.ColumnType_INT,
+				Description: "CPU requested by the container, in millicores.",
+				Transform:   transform.FromField("Container.Resources.Requests").TransformP(resourceListQuantity, corev1.ResourceCPU).TransformP(resourceQuantityToNumber, "millis"),
+			},
+			{
+				Name:        "cpu_limit_millicores",
+				Type:        proto.ColumnType_INT,
+				Description: "CPU limit of the container, in millicores.",
+				Transform:   transform.FromField("Container.Resources.Limits").TransformP(resourceListQuantity, corev1.ResourceCPU).TransformP(resourceQuantityToNumber, "millis"),
+			},
+			{
+				Name:        "memory_request_bytes",
+				Type:        proto.ColumnType_INT,
+				Description: "Memory requested by the container, in bytes.",
+				Transform:   transform.FromField("Container.Resources.Requests").TransformP(resourceListQuantity, corev1.ResourceMemory).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name:        "memory_limit_bytes",
+				Type:        proto.ColumnType_INT,
+				Description: "Memory limit of the container, in bytes.",
+				Transform:   transform.FromField("Container.Resources.Limits").TransformP(resourceListQuantity, corev1.ResourceMemory).Transform(resourceQuantityToNumber),
+			},
+			{
+				Name:        "volume_mounts",
+				Type:        proto.ColumnType_JSON,
+				Description: "Pod volumes to mount into the container's filesystem.",
+				Transform:   transform.FromField("Container.VolumeMounts"),
+			},
+			{
+				Name:        "liveness_probe",
+				Type:        proto.ColumnType_JSON,
+				Description: "Periodic probe of container liveness. Container will be restarted if the probe fails.",
+				Transform:   transform.FromField("Container.LivenessProbe"),
+			},
+			{
+				Name:        "readiness_probe",
+				Type:        proto.ColumnType_JSON,
+				Description: "Periodic probe of container service readiness. Container will be removed from service endpoints if the probe fails.",
+				Transform:   transform.FromField("Container.ReadinessProbe"),
+			},
+			{
+				Name: "startup_probe",
+				Type: proto.ColumnType_JSON,
+				Description: "StartupProbe indicates that the Pod has successfully initialized. " +
+					"If specified, no other probes are executed until this completes successfully.",
+				Transform: transform.FromField("Container.StartupProbe"),
+			},
+			{
+				Name:        "security_context",
+				Type:        proto.ColumnType_JSON,
+				Description: "Security options the container should be run with, as set on the container.",
+				Transform:   transform.FromField("Container.SecurityContext"),
+			},
+			{
+				Name: "run_as_user",
+				Type: proto.ColumnType_INT,
+				Description: "The UID to run the entrypoint of the container process, from the container's security context, " +
+					"or else the pod's. Null if neither sets it, in which case the image's user is used.",
+				Transform: transform.FromP(containerSecurityContext, "RunAsUser"),
+			},
+			{
+				Name: "run_as_non_root",
+				Type: proto.ColumnType_BOOL,
+				Description: "Indicates that the container must run as a non-root user, from the container's security context, " +
+					"or else the pod's.",
+				Transform: transform.FromP(containerSecurityContext, "RunAsNonRoot"),
+			},
+			{
+				Name:        "privileged",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Run container in privileged mode. Processes in privileged containers are essentially equivalent to root on the host.",
+				Transform:   transform.FromField("Container.SecurityContext.Privileged"),
+			},
+
+			// container status columns
+			{
+				Name:        "ready",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Specifies whether the container has passed its readiness probe.",
+				Transform:   transform.FromField("Status.Ready"),
+			},
+			{
+				Name:        "started",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Specifies whether the container has passed its startup probe.",
+				Transform:   transform.FromField("Status.Started"),
+			},
+			{
+				Name:        "restart_count",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of times the container has been restarted.",
+				Transform:   transform.FromField("Status.RestartCount"),
+			},
+			{
+				Name:        "image_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "ImageID of the container's image, as reported by the container runtime.",
+				Transform:   transform.FromField("Status.ImageID"),
+			},
+			{
+				Name:        "container_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "Container's ID in the format 'docker://<container_id>'.",
+				Transform:   transform.FromField("Status.ContainerID"),
+			},
+			{
+				Name:        "state",
+				Type:        proto.ColumnType_JSON,
+				Description: "Details about the container's current condition: waiting, running or terminated.",
+				Transform:   transform.FromField("Status.State"),
+			},
+			{
+				Name:        "last_state",
+				Type:        proto.ColumnType_JSON,
+				Description: "Details about the container's last termination condition.",
+				Transform:   transform.FromField("Status.LastTerminationState"),
+			},
+			{
+				Name:        "last_termination_reason",
+				Type:        proto.ColumnType_STRING,
+				Description: "Reason from the last termination of the container, e.g. OOMKilled or Error.",
+				Transform:   transform.FromField("Status.LastTerminationState.Terminated.Reason"),
+			},
+			{
+				Name:        "last_termination_exit_code",
+				Type:        proto.ColumnType_INT,
+				Description: "Exit status from the last termination of the container.",
+				Transform:   transform.FromField("Status.LastTerminationState.Terminated.ExitCode"),
+			},
+		},
+	}
+}
+
+// pod_container columns the API server can filter pods on, mapped to their
+// field selector paths
+var podContainerSelectableFields = map[string]string{
+	"pod_name":  "metadata.name",
+	"node_name": "spec.nodeName",
+}
+
+//// HYDRATE FUNCTIONS
+
+func listK8sPodContainers(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	logger.Trace("listK8sPodContainers")
+
+	streamContainers := func(item interface{}) {
+		if pod, ok := item.(*corev1.Pod); ok {
+			for _, container := range podContainers(pod) {
+				d.StreamListItem(ctx, container)
+			}
+		}
+	}
+
+	opts := getListOptions(d, podContainerSelectableFields)
+	if served, err := eachInformerCacheItem(ctx, d, podResource, true, opts, streamContainers); served {
+		return nil, err
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	err = eachNamespacedListItem(ctx, d, opts, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.CoreV1().Pods(namespace).List(ctx, opts)
+	}, func(item metav1.Object) {
+		streamContainers(item)
+	})
+
+	return nil, err
+}
@@ -3,9 +3,13 @@ package k8s
 import (
 	"context"
 
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/turbot/steampipe-plugin-sdk/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
 )
 
 func tableK8sReplicaSet(ctx context.Context) *plugin.Table {
@@ -13,44 +17,124 @@ func tableK8sReplicaSet(ctx context.Context) *plugin.Table {
 		Name:        "k8s_replicaset",
 		Description: "Kubernetes ReplicaSet ensures that a specified number of pod replicas are running at any given time.",
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.AllColumns([]string{"name", "namespace"}),
-			Hydrate:    getK8sReplicaSet,
+			KeyColumns: plugin.AllColumns([]string{"name", "namespace", "context_name"}),
+			Hydrate:    skipUnreachableContext(getK8sReplicaSet),
 		},
+		GetMatrixItem: BuildContextList,
 		List: &plugin.ListConfig{
-			Hydrate: listK8sReplicaSets,
+			Hydrate: skipUnreachableContext(listK8sReplicaSets),
 		},
-		Columns: k8sCommonColumns([]*plugin.Column{}),
+		Columns: k8sCommonColumns("replica set", []*plugin.Column{
+			// replicaset columns
+			{
+				Name:        "replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "Number of desired pods. Defaults to 1.",
+				Transform:   transform.FromField("Spec.Replicas"),
+			},
+			{
+				Name:        "status_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "The most recently observed number of replicas.",
+				Transform:   transform.FromField("Status.Replicas"),
+			},
+			{
+				Name:        "fully_labeled_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of pods that have labels matching the labels of the pod template of the replicaset.",
+				Transform:   transform.FromField("Status.FullyLabeledReplicas"),
+			},
+			{
+				Name:        "ready_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of ready replicas for this replica set.",
+				Transform:   transform.FromField("Status.ReadyReplicas"),
+			},
+			{
+				Name:        "available_replicas",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of available replicas (ready for at least minReadySeconds) for this replica set.",
+				Transform:   transform.FromField("Status.AvailableReplicas"),
+			},
+			{
+				Name:        "observed_generation",
+				Type:        proto.ColumnType_INT,
+				Description: "ObservedGeneration reflects the generation of the most recently observed ReplicaSet.",
+				Transform:   transform.FromField("Status.ObservedGeneration"),
+			},
+			{
+				Name: "min_ready_seconds",
+				Type: proto.ColumnType_INT,
+				Description: "Minimum number of seconds for which a newly created pod should be ready without any of its container crashing, for it to be considered available. " +
+					"Defaults to 0 (pod will be considered available as soon as it is ready).",
+				Transform: transform.FromField("Spec.MinReadySeconds"),
+			},
+			{
+				Name: "selector",
+				Type: proto.ColumnType_JSON,
+				Description: "Selector is a label query over pods that should match the replica count. " +
+					"Label keys and values that must match in order to be controlled by this replica set. " +
+					"It must match the pod template's labels.",
+				Transform: transform.FromField("Spec.Selector"),
+			},
+			{
+				Name:        "template",
+				Type:        proto.ColumnType_JSON,
+				Description: "Template is the object that describes the pod that will be created if insufficient replicas are detected.",
+				Transform:   transform.FromField("Spec.Template"),
+			},
+			{
+				Name:        "conditions",
+				Type:        proto.ColumnType_JSON,
+				Description: "Represents the latest available observations of a replica set's current state.",
+				Transform:   transform.FromField("Status.Conditions"),
+			},
+		}),
 	}
 }
 
+// replicaset columns the API server can filter on, mapped to their field selector paths
+var replicaSetSelectableFields = map[string]string{
+	"name": "metadata.name",
+}
+
 //// HYDRATE FUNCTIONS
 
+var replicaSetResource = appsv1.SchemeGroupVersion.WithResource("replicasets")
+
 func listK8sReplicaSets(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listK8sReplicaSets")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
-	if err != nil {
+	opts := getListOptions(d, replicaSetSelectableFields)
+	if served, err := listFromInformerCache(ctx, d, replicaSetResource, true, opts); served {
+		return nil, err
+	}
+	if served, err := listMetadataOnly(ctx, d, replicaSetResource, true, opts); served {
 		return nil, err
 	}
 
-	replicaSets, err := clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, item := range replicaSets.Items {
-		d.StreamListItem(ctx, item)
-	}
+	err = streamNamespacedList(ctx, d, opts, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts)
+	})
 
-	return nil, nil
+	return nil, err
 }
 
 func getK8sReplicaSet(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("getK8sReplicaSet")
 
-	clientset, err := GetNewClientset(ctx, d.ConnectionManager)
+	if !contextMatchesQuals(ctx, d) {
+		return nil, nil
+	}
+
+	clientset, err := GetNewClientset(ctx, d)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +142,20 @@ func getK8sReplicaSet(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 	name := d.KeyColumnQuals["name"].GetStringValue()
 	namespace := d.KeyColumnQuals["namespace"].GetStringValue()
 
-	rs, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
-	if err != nil && !isNotFoundError(err) {
+	if !namespaceAllowed(d, namespace) {
+		return nil, nil
+	}
+
+	if item, served, err := getFromInformerCache(ctx, d, replicaSetResource, namespace, name); served {
+		return item, err
+	}
+
+	var rs *appsv1.ReplicaSet
+	err = retryTransientErrors(ctx, func() (err error) {
+		rs, err = clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
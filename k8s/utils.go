@@ -5,67 +5,380 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
-	// "k8s.io/apimachinery/pkg/api/errors"
-	// metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	// "k8s.io/client-go/tools/clientcmd"
-	// "k8s.io/client-go/util/homedir"
-	//
-	// Uncomment to load all auth plugins
-	// _ "k8s.io/client-go/plugin/pkg/client/auth"
-	//
-	// Or uncomment to load specific auth plugins
-	// _ "k8s.io/client-go/plugin/pkg/client/auth/azure"
-	// _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
-	// _ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
-	// _ "k8s.io/client-go/plugin/pkg/client/auth/openstack"
-
-	"github.com/turbot/steampipe-plugin-sdk/connection"
+	// register the auth-provider plugins (azure, gcp, oidc and openstack)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/plugin/transform"
 )
 
-func GetNewClientset(ctx context.Context, connectionManager *connection.Manager) (*kubernetes.Clientset, error) {
+// checkConnectionTimeout bounds the server version probe made when a clientset is created
+const checkConnectionTimeout = 10 * time.Second
+
+func GetNewClientset(ctx context.Context, d *plugin.QueryData) (*kubernetes.Clientset, error) {
+	clientset, _, err := getClientset(ctx, d)
+	return clientset, err
+}
+
+// getClientset returns the clientset for the connection and matrix item, along
+// with the key identifying the cluster, context and credentials it connects with
+func getClientset(ctx context.Context, d *plugin.QueryData) (*kubernetes.Clientset, string, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("GetNewClientset")
 
-	// have we already created and cached the session?
-	serviceCacheKey := "k8s"
+	// have we already created and cached the session? The key is derived
+	// without loading the kubeconfig, so cache hits stay cheap for the get
+	// hydrate of every row
+	serviceCacheKey, err := getClientCacheKey(ctx, d)
+	if err != nil {
+		return nil, "", err
+	}
 
-	if cachedData, ok := connectionManager.Cache.Get(serviceCacheKey); ok {
-		logger.Warn("!!!! Clientset Found in Cache !!!!")
-		return cachedData.(*kubernetes.Clientset), nil
+	if cachedData, ok := d.ConnectionManager.Cache.Get(serviceCacheKey); ok {
+		logger.Trace("GetNewClientset", "cached clientset", serviceCacheKey)
+		return cachedData.(*kubernetes.Clientset), serviceCacheKey, nil
 	}
 
-	home, err := os.UserHomeDir()
+	config, source, execTimeout, err := getClientConfig(ctx, d)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// create the clientset
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, "", newConnectionError(stepCreateClientset, source, err)
+	}
+
+	// probe the server version so that connection problems surface here with
+	// a clear message, rather than as an opaque error from the first list call
+	if err := checkConnection(config, execTimeout); err != nil {
+		return nil, "", newConnectionError(stepConnect, source, err)
+	}
+
+	d.ConnectionManager.Cache.Set(serviceCacheKey, clientset)
+
+	return clientset, serviceCacheKey, nil
+}
+
+// getMetadataClient returns a client for the metadata of objects in the
+// cluster of the connection and matrix item
+func getMetadataClient(ctx context.Context, d *plugin.QueryData) (metadata.Interface, error) {
+	logger := plugin.Logger(ctx)
+	logger.Trace("getMetadataClient")
+
+	clientKey, err := getClientCacheKey(ctx, d)
 	if err != nil {
 		return nil, err
 	}
 
-	kubeconfig := filepath.Join(home, ".kube", "config")
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	serviceCacheKey := clientKey + "|metadata"
+	if cachedData, ok := d.ConnectionManager.Cache.Get(serviceCacheKey); ok {
+		return cachedData.(metadata.Interface), nil
+	}
+
+	// the clientset checks the connection, so problems are reported the same way
+	if _, _, err := getClientset(ctx, d); err != nil {
+		return nil, err
+	}
+
+	config, source, _, err := getClientConfig(ctx, d)
 	if err != nil {
-		panic(err.Error())
+		return nil, err
 	}
 
-	// create the clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	client, err := metadata.NewForConfig(config)
 	if err != nil {
-		panic(err.Error())
+		return nil, newConnectionError(stepCreateClientset, source, err)
 	}
 
-	connectionManager.Cache.Set(serviceCacheKey, clientset)
-	if _, ok := connectionManager.Cache.Get(serviceCacheKey); ok {
-		logger.Warn("!!!! Clientset Found in Cache after adding !!!!")
-	} else {
-		logger.Warn("!!!! Clientset NOT Found in Cache after adding !!!!")
+	d.ConnectionManager.Cache.Set(serviceCacheKey, client)
+
+	return client, nil
+}
+
+// getMatrixConfig returns the connection config for the current matrix item
+func getMatrixConfig(ctx context.Context, d *plugin.QueryData) k8sConfig {
+	k8sConfig := GetConfig(d.Connection)
+
+	// when fanning out across contexts, connect to the context of this matrix item
+	if contextName, ok := plugin.GetMatrixItem(ctx)[matrixKeyContext].(string); ok && contextName != "" {
+		k8sConfig.ConfigContext = &contextName
 	}
-	return clientset, err
+
+	return k8sConfig
+}
+
+// getClientConfig resolves the client config for the connection and matrix
+// item, with authentication and client options applied. It also returns the
+// timeout for exec credential plugins.
+func getClientConfig(ctx context.Context, d *plugin.QueryData) (*rest.Config, clientSource, time.Duration, error) {
+	logger := plugin.Logger(ctx)
+
+	k8sConfig := getMatrixConfig(ctx, d)
+
+	config, source, err := getRestConfig(ctx, k8sConfig)
+	if err != nil {
+		return nil, source, 0, newConnectionError(stepLoadConfig, source, err)
+	}
+
+	execTimeout, err := getExecTimeout(k8sConfig)
+	if err != nil {
+		return nil, source, 0, newConnectionError(stepConfigureAuth, source, err)
+	}
+	if err := configureExecProvider(config, k8sConfig, execTimeout); err != nil {
+		return nil, source, 0, newConnectionError(stepConfigureAuth, source, err)
+	}
+	if err := configureClientOptions(config, k8sConfig, logger); err != nil {
+		return nil, source, 0, newConnectionError(stepCreateClientset, source, err)
+	}
+
+	return config, source, execTimeout, nil
+}
+
+// getClientCacheKey returns the key clients for the connection and matrix
+// item are cached under. It is derived from the kubeconfig files, their
+// modification times and the context, cluster and user the connection
+// selects, without loading the kubeconfig, so a rewritten kubeconfig yields a
+// new key but an unchanged one costs only a stat of each file.
+func getClientCacheKey(ctx context.Context, d *plugin.QueryData) (string, error) {
+	k8sConfig := getMatrixConfig(ctx, d)
+
+	if k8sConfig.Host != nil && *k8sConfig.Host != "" {
+		return clientSource{Server: *k8sConfig.Host}.cacheKey(), nil
+	}
+
+	var source clientSource
+	loadingRules, err := getLoadingRules(k8sConfig)
+	if err != nil {
+		return "", newConnectionError(stepLoadConfig, source, err)
+	}
+
+	overrides := getConfigOverrides(k8sConfig)
+	source = clientSource{
+		ConfigPath: strings.Join(loadingRules.GetLoadingPrecedence(), string(filepath.ListSeparator)),
+		Context:    overrides.CurrentContext,
+		Cluster:    overrides.Context.Cluster,
+		User:       overrides.Context.AuthInfo,
+		ModTime:    latestModTime(loadingRules.GetLoadingPrecedence()),
+	}
+	return source.cacheKey(), nil
+}
+
+// clientSource identifies the kubeconfig and context a client config was
+// loaded from
+type clientSource struct {
+	ConfigPath string
+	Context    string
+	Cluster    string
+	User       string
+	Server     string
+	// Namespace is the default namespace of the context, or of the service
+	// account in cluster, which is not part of the cache key
+	Namespace string
+	// ModTime is the latest modification time of the kubeconfig files, so that
+	// a rewritten kubeconfig (e.g. after a token rotation) yields a new cache key
+	ModTime time.Time
+}
+
+// cacheKey derives a client cache key from the source, so that connections to
+// different clusters or contexts never share a client
+func (s clientSource) cacheKey() string {
+	return strings.Join([]string{
+		"k8s",
+		s.ConfigPath,
+		s.Context,
+		s.Cluster,
+		s.User,
+		s.Server,
+		strconv.FormatInt(s.ModTime.UnixNano(), 10),
+	}, "|")
+}
+
+// getRestConfig resolves the client config. If the connection sets host, the
+// config is built from the connection arguments alone. Otherwise, it uses the
+// standard kubeconfig loading chain: the config_path connection argument, then the files listed in
+// KUBECONFIG (merged in order), then ~/.kube/config. If none of these yield a
+// configuration and the plugin is running in a pod, the pod's service account
+// is used instead.
+func getRestConfig(ctx context.Context, k8sConfig k8sConfig) (*rest.Config, clientSource, error) {
+	logger := plugin.Logger(ctx)
+
+	var source clientSource
+
+	// a host in the connection config bypasses the kubeconfig entirely
+	directConfig, err := getDirectConfig(k8sConfig)
+	if err != nil {
+		return nil, source, err
+	}
+	if directConfig != nil {
+		return directConfig, clientSource{Server: directConfig.Host}, nil
+	}
+
+	loadingRules, err := getLoadingRules(k8sConfig)
+	if err != nil {
+		return nil, source, err
+	}
+	source.ConfigPath = strings.Join(loadingRules.GetLoadingPrecedence(), string(filepath.ListSeparator))
+	source.ModTime = latestModTime(loadingRules.GetLoadingPrecedence())
+
+	overrides := getConfigOverrides(k8sConfig)
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	source.Context = overrides.CurrentContext
+	if rawConfig, err := clientConfig.RawConfig(); err == nil {
+		if source.Context == "" {
+			source.Context = rawConfig.CurrentContext
+		}
+		if context, ok := rawConfig.Contexts[source.Context]; ok {
+			source.Cluster = context.Cluster
+			source.User = context.AuthInfo
+		}
+	}
+	if overrides.Context.Cluster != "" {
+		source.Cluster = overrides.Context.Cluster
+	}
+	if overrides.Context.AuthInfo != "" {
+		source.User = overrides.Context.AuthInfo
+	}
+
+	// the namespace override is the namespace connection argument
+	if namespace, _, err := clientConfig.Namespace(); err == nil {
+		source.Namespace = namespace
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err == nil {
+		source.Server = config.Host
+		return config, source, nil
+	}
+	if !clientcmd.IsEmptyConfig(err) || loadingRules.ExplicitPath != "" {
+		return nil, source, err
+	}
+
+	// no kubeconfig was found - fall back to the service account if we are running in a cluster
+	inClusterConfig, inClusterErr := rest.InClusterConfig()
+	if inClusterErr != nil {
+		logger.Trace("getRestConfig", "in-cluster config unavailable", inClusterErr)
+		return nil, source, err
+	}
+	logger.Trace("getRestConfig", "using in-cluster config", inClusterConfig.Host)
+	return inClusterConfig, clientSource{Context: "in-cluster", Server: inClusterConfig.Host, Namespace: source.Namespace}, nil
+}
+
+// getLoadingRules returns the kubeconfig loading rules for the connection,
+// honouring config_path ahead of KUBECONFIG and ~/.kube/config
+func getLoadingRules(k8sConfig k8sConfig) (*clientcmd.ClientConfigLoadingRules, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if k8sConfig.ConfigPath != nil && *k8sConfig.ConfigPath != "" {
+		path, err := expandPath(*k8sConfig.ConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		loadingRules.ExplicitPath = path
+	}
+	return loadingRules, nil
+}
+
+// latestModTime returns the most recent modification time of the given files,
+// ignoring any that do not exist
+func latestModTime(paths []string) time.Time {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// checkConnection verifies that the API server is reachable and accepts our
+// credentials by requesting its version
+func checkConnection(config *rest.Config, execTimeout time.Duration) error {
+	probeConfig := rest.CopyConfig(config)
+	if probeConfig.Timeout == 0 {
+		probeConfig.Timeout = checkConnectionTimeout
+	}
+	// the request timeout includes fetching credentials, which the exec
+	// credential plugin wrapper bounds by exec_timeout
+	if probeConfig.ExecProvider != nil {
+		probeConfig.Timeout += execTimeout
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(probeConfig)
+	if err != nil {
+		return err
+	}
+
+	_, err = discoveryClient.ServerVersion()
+	return err
+}
+
+// parsePositiveDuration parses the duration connection argument name, which
+// must be greater than zero
+func parsePositiveDuration(name string, value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", name, value, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be greater than zero", name, value)
+	}
+	return duration, nil
+}
+
+// expandPath expands a leading ~ in path to the user's home directory
+func expandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// getConfigOverrides builds the clientcmd overrides for the context, cluster,
+// user and namespace set in the connection config
+func getConfigOverrides(k8sConfig k8sConfig) *clientcmd.ConfigOverrides {
+	overrides := &clientcmd.ConfigOverrides{}
+
+	if k8sConfig.ConfigContext != nil {
+		overrides.CurrentContext = *k8sConfig.ConfigContext
+	}
+	if k8sConfig.Cluster != nil {
+		overrides.Context.Cluster = *k8sConfig.Cluster
+	}
+	if k8sConfig.User != nil {
+		overrides.Context.AuthInfo = *k8sConfig.User
+	}
+	if k8sConfig.Namespace != nil {
+		overrides.Context.Namespace = *k8sConfig.Namespace
+	}
+
+	return overrides
 }
 
 func v1TimeToRFC3339(ctx context.Context, d *transform.TransformData) (interface{}, error) {
@@ -86,9 +399,89 @@ func v1TimeToRFC3339(ctx context.Context, d *transform.TransformData) (interface
 	}
 }
 
-func isNotFoundError(err error) bool {
-	if strings.HasSuffix(err.Error(), "not found") {
-		return true
+// typeMetaFromScheme returns the kind or api_version (given as the param) of
+// the row's object. Objects decoded from list and get responses have an empty
+// TypeMeta, so the kind is looked up from the type of the object instead.
+func typeMetaFromScheme(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	object, ok := d.HydrateItem.(runtime.Object)
+	if !ok {
+		return nil, nil
+	}
+
+	gvks, _, err := scheme.Scheme.ObjectKinds(object)
+	if err != nil {
+		return nil, err
+	}
+
+	switch d.Param {
+	case "kind":
+		return gvks[0].Kind, nil
+	case "api_version":
+		return gvks[0].GroupVersion().String(), nil
+	default:
+		return nil, fmt.Errorf("typeMetaFromScheme: invalid param %v", d.Param)
+	}
+}
+
+// resourceQuantityToNumber converts a resource quantity, such as "250m" or
+// "1Gi", to an integer SQL can do arithmetic with. With the param "millis" it
+// returns thousandths of the unit, e.g. CPU millicores. Otherwise it returns
+// whole units, e.g. bytes of memory, rounded up.
+func resourceQuantityToNumber(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	var quantity resource.Quantity
+	switch v := d.Value.(type) {
+	case nil:
+		return nil, nil
+	case resource.Quantity:
+		quantity = v
+	case *resource.Quantity:
+		if v == nil {
+			return nil, nil
+		}
+		quantity = *v
+	case string:
+		parsed, err := resource.ParseQuantity(v)
+		if err != nil {
+			return nil, err
+		}
+		quantity = parsed
+	default:
+		return nil, fmt.Errorf("Invalid quantity format %T!\n", v)
+	}
+
+	if d.Param == "millis" {
+		return quantity.MilliValue(), nil
+	}
+	return quantity.Value(), nil
+}
+
+// resourceListQuantity returns the quantity of the resource given as the param
+// from a resource list, such as a node's capacity, or nil if it is not listed
+func resourceListQuantity(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	resources, ok := d.Value.(corev1.ResourceList)
+	if !ok {
+		return nil, nil
+	}
+	if quantity, ok := resources[d.Param.(corev1.ResourceName)]; ok {
+		return quantity, nil
+	}
+	return nil, nil
+}
+
+// intOrStringToString returns an int-or-string value, such as a rolling update
+// max surge of 1 or "25%", as a string
+func intOrStringToString(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	switch v := d.Value.(type) {
+	case nil:
+		return nil, nil
+	case intstr.IntOrString:
+		return v.String(), nil
+	case *intstr.IntOrString:
+		if v == nil {
+			return nil, nil
+		}
+		return v.String(), nil
+	default:
+		return nil, fmt.Errorf("Invalid int or string format %T!\n", v)
 	}
-	return false
 }
@@ -0,0 +1,33 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePositiveDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30s", want: 30 * time.Second},
+		{value: "1m30s", want: 90 * time.Second},
+		{value: "0s", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-5s", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parsePositiveDuration("exec_timeout", test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parsePositiveDuration(%q) error = %v, want error %v", test.value, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("parsePositiveDuration(%q) = %s, want %s", test.value, got, test.want)
+			}
+		})
+	}
+}
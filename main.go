@@ -1,11 +1,19 @@
 package main
 
 import (
+	"os"
+
 	"github.com/turbot/steampipe-plugin-k8s/k8s"
 	"github.com/turbot/steampipe-plugin-sdk/plugin"
 )
 
 func main() {
+	// the plugin runs exec credential plugins through itself, to bound how
+	// long each run may take
+	if len(os.Args) > 1 && os.Args[1] == k8s.ExecWrapperArg {
+		os.Exit(k8s.RunExecWrapper(os.Args[2:]))
+	}
+
 	plugin.Serve(&plugin.ServeOpts{
 		PluginFunc: k8s.Plugin})
 }